@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
+	"github.com/BlackBX/service-framework/server"
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Service starts a second HTTP server for management endpoints on admin-port, the
+// router.Modules in the "admin" group are mounted on it without the middleware of
+// the public router. It hosts pprof, a dump of the configuration and metrics, and
+// the health checks when health.AdminService is used.
+// nolint: gomnd
+var Service = dependency.Service{
+	Name:     "admin",
+	Requires: []string{"config", "logging", "response"},
+	ConfigFunc: func(flags dependency.FlagSet) {
+		flags.String("admin-host", "127.0.0.1", "The IP to start the admin server on")
+		flags.Int("admin-port", 8081, "The port to start the admin server on, 0 disables the admin server")
+	},
+	Dependencies: fx.Provide(
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewPprofModule,
+		},
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewConfigModule,
+		},
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewMetricsModule,
+		},
+	),
+	InvokeFunc: Invoke,
+}
+
+// NewRouter creates the router of the admin server with the given modules
+func NewRouter(provider response.ResponderProvider, modules []router.Module) *mux.Router {
+	adminRouter := mux.NewRouter()
+	for _, module := range modules {
+		module.Router(adminRouter.PathPrefix(module.PathPrefix()).Subrouter())
+	}
+	adminRouter.NotFoundHandler = router.New404Handler(provider)
+	adminRouter.MethodNotAllowedHandler = router.New405Handler(provider)
+	return adminRouter
+}
+
+// Params are the dependencies required to start the admin server
+type Params struct {
+	fx.In
+
+	Lifecycle        fx.Lifecycle
+	Shutdowner       fx.Shutdowner
+	Config           dependency.ConfigGetter
+	Logger           *zap.Logger
+	ResponseProvider response.ResponderProvider
+	Modules          []router.Module       `group:"admin"`
+	Shutdown         server.ShutdownConfig `optional:"true"`
+}
+
+// Invoke starts the admin server with the lifecycle of the application, unless
+// admin-port is 0
+func Invoke(params Params) {
+	port := params.Config.GetInt("admin-port")
+	if port == 0 {
+		return
+	}
+	logger := params.Logger.With(zap.String("server", "admin"))
+	adminServer := &http.Server{
+		Addr:              fmt.Sprintf("%s:%d", params.Config.GetString("admin-host"), port),
+		Handler:           NewRouter(params.ResponseProvider, params.Modules),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	shutdown := server.NewShutdown(server.ShutdownConfig{Grace: params.Shutdown.Grace}, logger)
+	shutdown.Connections.Track(adminServer)
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: server.StartServer(adminServer, logger, params.Shutdowner),
+		OnStop:  shutdown.Stop(adminServer),
+	})
+}
+
+// NewPprofModule creates the module that serves the runtime profiling data of
+// the application at /debug/pprof
+func NewPprofModule() router.Module {
+	return router.Module{
+		Path: "debug/pprof",
+		Router: func(router *mux.Router) {
+			router.HandleFunc("/cmdline", pprof.Cmdline)
+			router.HandleFunc("/profile", pprof.Profile)
+			router.HandleFunc("/symbol", pprof.Symbol)
+			router.HandleFunc("/trace", pprof.Trace)
+			router.PathPrefix("/").HandlerFunc(pprof.Index)
+		},
+	}
+}
+
+// NewMetricsModule creates the module that serves the metrics published with
+// the expvar package at /metrics
+func NewMetricsModule() router.Module {
+	return router.Module{
+		Path: "metrics",
+		Router: func(router *mux.Router) {
+			router.Handle("", expvar.Handler())
+		},
+	}
+}
+
+// ConfigParams are the dependencies required to dump the configuration
+type ConfigParams struct {
+	fx.In
+
+	Cmd              *cobra.Command
+	ResponseProvider response.ResponderProvider
+	FlagDescriber    dependency.FlagDescriber `optional:"true"`
+}
+
+// ConfigValue is the effective value of a flag, and where the value came from
+type ConfigValue struct {
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// NewConfigModule creates the module that serves the effective configuration
+// of the application at /config, the values of secret flags are masked
+func NewConfigModule(params ConfigParams) router.Module {
+	return router.Module{
+		Path: "config",
+		Router: func(router *mux.Router) {
+			router.HandleFunc("", func(rw http.ResponseWriter, r *http.Request) {
+				params.ResponseProvider.
+					Responder(rw, r).
+					Respond(http.StatusOK, DumpConfig(params.Cmd, params.FlagDescriber))
+			}).Methods(http.MethodGet)
+		},
+	}
+}
+
+// DumpConfig returns the effective value of each of the flags of the command
+func DumpConfig(cmd *cobra.Command, describer dependency.FlagDescriber) map[string]ConfigValue {
+	values := map[string]ConfigValue{}
+	cmd.Flags().VisitAll(func(flag *pflag.Flag) {
+		value, source := flag.Value.String(), "default"
+		if flag.Changed {
+			source = "flag"
+		}
+		if describer != nil {
+			value, source = describer.DescribeFlag(flag)
+		}
+		if value != "" && dependency.IsSecretFlag(flag.Name) {
+			value = "********"
+		}
+		values[flag.Name] = ConfigValue{Value: value, Source: source}
+	})
+	return values
+}
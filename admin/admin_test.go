@@ -0,0 +1,102 @@
+package admin_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/BlackBX/service-framework/admin"
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/health"
+	"github.com/BlackBX/service-framework/logging"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestService(t *testing.T) {
+	port := freePort(t)
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(logging.Service).
+		WithService(response.Service).
+		WithService(health.AdminService).
+		WithService(admin.Service).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{
+		"--admin-host=127.0.0.1",
+		fmt.Sprintf("--admin-port=%d", port),
+		"--newrelic-license-key=secret",
+	})
+	cmd.PersistentFlags().String("newrelic-license-key", "", "")
+	statusCodes := map[string]int{}
+	var dump map[string]admin.ConfigValue
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		app := builder.BuildTest(t)
+		app.RequireStart()
+		defer app.RequireStop()
+		for _, path := range []string{"/health/ready", "/debug/pprof/", "/debug/pprof/heap", "/metrics", "/config", "/missing"} {
+			response, err := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", port, path))
+			if err != nil {
+				t.Fatal(err)
+			}
+			statusCodes[path] = response.StatusCode
+			if path == "/config" {
+				if err := json.NewDecoder(response.Body).Decode(&dump); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_ = response.Body.Close()
+		}
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	expectedStatusCodes := map[string]int{
+		"/health/ready":     http.StatusOK,
+		"/debug/pprof/":     http.StatusOK,
+		"/debug/pprof/heap": http.StatusOK,
+		"/metrics":          http.StatusOK,
+		"/config":           http.StatusOK,
+		"/missing":          http.StatusNotFound,
+	}
+	for path, expected := range expectedStatusCodes {
+		if statusCodes[path] != expected {
+			t.Errorf("expected (%s) to respond with (%d), got (%d)", path, expected, statusCodes[path])
+		}
+	}
+	expectedValue := admin.ConfigValue{Value: "********", Source: "flag"}
+	if dump["newrelic-license-key"] != expectedValue {
+		t.Errorf("expected the license key to be (%+v), got (%+v)", expectedValue, dump["newrelic-license-key"])
+	}
+}
+
+func TestServiceDisabled(t *testing.T) {
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(logging.Service).
+		WithService(response.Service).
+		WithService(admin.Service).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{"--admin-port=0"})
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		builder.BuildTest(t).RequireStart().RequireStop()
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+}
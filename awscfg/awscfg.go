@@ -8,7 +8,8 @@ import (
 
 // Service is a dependency that will provide a gizmo aws.Config struct
 var Service = dependency.Service{
-	Name: "aws-cfg",
+	Name:     "aws-cfg",
+	Requires: []string{"config"},
 	ConfigFunc: func(set dependency.FlagSet) {
 		set.String("aws-access-key-id", "", "The key to use to communicate")
 		set.String("aws-mfa-serial-number", "", "The mfa serial number to communicate to AWS with")
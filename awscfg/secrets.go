@@ -0,0 +1,150 @@
+package awscfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	gizmoaws "github.com/NYTimes/gizmo/config/aws"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/aws/aws-sdk-go/service/ssm"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// SecretsService allows configuration values to reference secrets held in the AWS
+// SSM Parameter Store, such as ssm:///prod/pg/password, and AWS Secrets Manager,
+// such as secretsmanager:///prod/pg#password
+var SecretsService = dependency.Service{
+	Name:     "aws-secrets",
+	Requires: []string{"config", "aws-cfg"},
+	Dependencies: fx.Provide(
+		fx.Annotated{
+			Group:  "secret-resolvers",
+			Target: NewSSMSecretResolver,
+		},
+		fx.Annotated{
+			Group:  "secret-resolvers",
+			Target: NewSecretsManagerSecretResolver,
+		},
+	),
+}
+
+// NewSession creates a new *session.Session configured by the given aws.Config
+func NewSession(cfg gizmoaws.Config) (*session.Session, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, fmt.Errorf("could not create AWS session, got error (%w)", err)
+	}
+	var creds *credentials.Credentials
+	switch {
+	case cfg.AccessKey != "":
+		creds = credentials.NewStaticCredentials(cfg.AccessKey, cfg.SecretKey, cfg.SessionToken)
+	case cfg.RoleARN != "":
+		creds = stscreds.NewCredentials(sess, cfg.RoleARN, func(provider *stscreds.AssumeRoleProvider) {
+			if cfg.MFASerialNumber != "" {
+				provider.SerialNumber = aws.String(cfg.MFASerialNumber)
+				provider.TokenProvider = stscreds.StdinTokenProvider
+			}
+		})
+	}
+	return sess.Copy(&aws.Config{
+		Credentials: creds,
+		Region:      aws.String(cfg.Region),
+		Endpoint:    cfg.EndpointURL,
+	}), nil
+}
+
+// lazySession creates the *session.Session the first time that it is required,
+// so that the session is only created when there are secrets to resolve
+func lazySession(settings *viper.Viper) func() (*session.Session, error) {
+	var (
+		once sync.Once
+		sess *session.Session
+		err  error
+	)
+	return func() (*session.Session, error) {
+		once.Do(func() {
+			sess, err = NewSession(NewAWSCfg(settings))
+		})
+		return sess, err
+	}
+}
+
+// NewSSMSecretResolver creates a config.SecretResolver that resolves references to
+// parameters in the AWS SSM Parameter Store, for example ssm:///prod/pg/password
+func NewSSMSecretResolver(settings *viper.Viper) config.SecretResolver {
+	getSession := lazySession(settings)
+	return config.SecretResolver{
+		Scheme: "ssm",
+		Resolve: func(reference *url.URL) (string, error) {
+			sess, err := getSession()
+			if err != nil {
+				return "", err
+			}
+			output, err := ssm.New(sess).GetParameter(&ssm.GetParameterInput{
+				Name:           aws.String(reference.Path),
+				WithDecryption: aws.Bool(true),
+			})
+			if err != nil {
+				return "", fmt.Errorf("could not get the SSM parameter (%s), got error (%w)", reference.Path, err)
+			}
+			if output.Parameter == nil {
+				return "", fmt.Errorf("the SSM parameter (%s) has no value", reference.Path)
+			}
+			return aws.StringValue(output.Parameter.Value), nil
+		},
+	}
+}
+
+// NewSecretsManagerSecretResolver creates a config.SecretResolver that resolves
+// references to secrets in AWS Secrets Manager, for example secretsmanager:///prod/pg.
+// If the secret is a JSON object, a single field can be selected with the fragment,
+// for example secretsmanager:///prod/pg#password
+func NewSecretsManagerSecretResolver(settings *viper.Viper) config.SecretResolver {
+	getSession := lazySession(settings)
+	return config.SecretResolver{
+		Scheme: "secretsmanager",
+		Resolve: func(reference *url.URL) (string, error) {
+			sess, err := getSession()
+			if err != nil {
+				return "", err
+			}
+			name := strings.TrimPrefix(reference.Path, "/")
+			output, err := secretsmanager.New(sess).GetSecretValue(&secretsmanager.GetSecretValueInput{
+				SecretId: aws.String(name),
+			})
+			if err != nil {
+				return "", fmt.Errorf("could not get the secret (%s), got error (%w)", name, err)
+			}
+			secret := aws.StringValue(output.SecretString)
+			if reference.Fragment == "" {
+				return secret, nil
+			}
+			return secretField(secret, reference.Fragment)
+		},
+	}
+}
+
+func secretField(secret, field string) (string, error) {
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(secret), &fields); err != nil {
+		return "", fmt.Errorf("could not parse the secret as JSON, got error (%w)", err)
+	}
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("the field (%s) was not found in the secret", field)
+	}
+	if stringValue, ok := value.(string); ok {
+		return stringValue, nil
+	}
+	return fmt.Sprint(value), nil
+}
@@ -0,0 +1,82 @@
+package awscfg_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BlackBX/service-framework/awscfg"
+	"github.com/BlackBX/service-framework/config"
+	"github.com/spf13/cobra"
+)
+
+func newStandInAWS(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		input := map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			t.Errorf("could not decode request, got error (%s)", err)
+		}
+		var output interface{}
+		switch r.Header.Get("X-Amz-Target") {
+		case "AmazonSSM.GetParameter":
+			if input["Name"] != "/prod/pg/password" || input["WithDecryption"] != true {
+				t.Errorf("unexpected SSM input (%+v)", input)
+			}
+			output = map[string]interface{}{
+				"Parameter": map[string]interface{}{"Value": "hunter2"},
+			}
+		case "secretsmanager.GetSecretValue":
+			if input["SecretId"] != "prod/redis" {
+				t.Errorf("unexpected Secrets Manager input (%+v)", input)
+			}
+			output = map[string]interface{}{
+				"SecretString": `{"password": "hunter3"}`,
+			}
+		default:
+			t.Errorf("unexpected target (%s)", r.Header.Get("X-Amz-Target"))
+		}
+		rw.Header().Set("Content-Type", "application/x-amz-json-1.1")
+		if err := json.NewEncoder(rw).Encode(output); err != nil {
+			t.Error(err)
+		}
+	}))
+}
+
+func TestSecretResolvers(t *testing.T) {
+	server := newStandInAWS(t)
+	defer server.Close()
+
+	cmd := &cobra.Command{}
+	awscfg.Service.ConfigFunc(cmd.Flags())
+	cmd.Flags().String("postgres-password", "ssm:///prod/pg/password", "")
+	cmd.Flags().String("redis-password", "secretsmanager:///prod/redis#password", "")
+	for flag, value := range map[string]string{
+		"aws-endpoint-url":      server.URL,
+		"aws-access-key-id":     "access-key",
+		"aws-secret-access-key": "secret-key",
+	} {
+		if err := cmd.Flags().Set(flag, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := config.NewStore(
+		cfg,
+		awscfg.NewSSMSecretResolver(cfg),
+		awscfg.NewSecretsManagerSecretResolver(cfg),
+	)
+	if err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if value := store.GetString("postgres-password"); value != "hunter2" {
+		t.Errorf("expected (hunter2), got (%s)", value)
+	}
+	if value := store.GetString("redis-password"); value != "hunter3" {
+		t.Errorf("expected (hunter3), got (%s)", value)
+	}
+}
@@ -0,0 +1,414 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+)
+
+var (
+	durationType     = reflect.TypeOf(time.Duration(0))
+	configGetterType = reflect.TypeOf((*dependency.ConfigGetter)(nil)).Elem()
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// FieldError describes why the value of a flag is invalid
+type FieldError struct {
+	Flag   string
+	Reason string
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s %s", e.Flag, e.Reason)
+}
+
+// ValidationError is the aggregate of all of the invalid flags of a bound struct
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		reasons = append(reasons, field.Error())
+	}
+	return fmt.Sprintf("invalid configuration: %s", strings.Join(reasons, ", "))
+}
+
+// Bind creates a dependency.Service that registers a flag for each field of the target
+// struct with a flag tag, and provides the type of the target populated from the
+// configuration. The flag, default, usage and validate tags are supported, for example:
+//
+//	Port int `flag:"postgres-port" default:"5432" usage:"The port" validate:"min=1,max=65535"`
+//
+// The application will fail to start if any of the values are invalid.
+func Bind(target interface{}) dependency.Service {
+	targetType := reflect.TypeOf(target)
+	if targetType.Kind() == reflect.Ptr {
+		targetType = targetType.Elem()
+	}
+	constructorType := reflect.FuncOf(
+		[]reflect.Type{configGetterType},
+		[]reflect.Type{targetType, errorType},
+		false,
+	)
+	constructor := reflect.MakeFunc(constructorType, func(args []reflect.Value) []reflect.Value {
+		value := reflect.New(targetType)
+		err := Populate(args[0].Interface().(dependency.ConfigGetter), value.Interface())
+		errValue := reflect.Zero(errorType)
+		if err != nil {
+			errValue = reflect.ValueOf(&err).Elem()
+		}
+		return []reflect.Value{value.Elem(), errValue}
+	})
+	return dependency.Service{
+		Requires: []string{"config"},
+		ConfigFunc: func(set dependency.FlagSet) {
+			RegisterFlags(set, target)
+		},
+		Constructor: constructor.Interface(),
+	}
+}
+
+type boundField struct {
+	value        reflect.Value
+	flag         string
+	defaultValue string
+	usage        string
+	validate     string
+}
+
+// boundFields walks the fields of the struct, and any nested structs, returning
+// each field that has a flag tag
+func boundFields(value reflect.Value) []boundField {
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("config: cannot bind (%s), it is not a struct", value.Type()))
+	}
+	fields := make([]boundField, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		flag, ok := field.Tag.Lookup("flag")
+		if !ok {
+			if field.Type.Kind() == reflect.Struct {
+				fields = append(fields, boundFields(value.Field(i))...)
+			}
+			continue
+		}
+		fields = append(fields, boundField{
+			value:        value.Field(i),
+			flag:         flag,
+			defaultValue: field.Tag.Get("default"),
+			usage:        field.Tag.Get("usage"),
+			validate:     field.Tag.Get("validate"),
+		})
+	}
+	return fields
+}
+
+// RegisterFlags registers a flag for each field of the target struct that has a flag tag,
+// it will panic if a field has a type that cannot be a flag, or an invalid default
+func RegisterFlags(set dependency.FlagSet, target interface{}) {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr {
+		pointer := reflect.New(value.Type())
+		pointer.Elem().Set(value)
+		value = pointer
+	}
+	for _, field := range boundFields(value) {
+		if err := registerFlag(set, field); err != nil {
+			panic(fmt.Sprintf("config: cannot register flag (%s), got error (%s)", field.flag, err))
+		}
+	}
+}
+
+// nolint: gocyclo
+func registerFlag(set dependency.FlagSet, field boundField) error {
+	name, defaultValue, usage := field.flag, field.defaultValue, field.usage
+	if field.value.Type() == durationType {
+		duration, err := parseDuration(defaultValue)
+		set.Duration(name, duration, usage)
+		return err
+	}
+	switch field.value.Kind() {
+	case reflect.String:
+		set.String(name, defaultValue, usage)
+	case reflect.Bool:
+		boolean, err := parseBool(defaultValue)
+		set.Bool(name, boolean, usage)
+		return err
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		integer, err := parseInt(defaultValue)
+		switch field.value.Kind() {
+		case reflect.Int32:
+			set.Int32(name, int32(integer), usage)
+		case reflect.Int64:
+			set.Int64(name, integer, usage)
+		default:
+			set.Int(name, int(integer), usage)
+		}
+		return err
+	case reflect.Uint, reflect.Uint32, reflect.Uint64:
+		integer, err := parseUint(defaultValue)
+		switch field.value.Kind() {
+		case reflect.Uint32:
+			set.Uint32(name, uint32(integer), usage)
+		case reflect.Uint64:
+			set.Uint64(name, integer, usage)
+		default:
+			set.Uint(name, uint(integer), usage)
+		}
+		return err
+	case reflect.Float64:
+		float, err := parseFloat(defaultValue)
+		set.Float64(name, float, usage)
+		return err
+	case reflect.Slice:
+		return registerSliceFlag(set, field)
+	case reflect.Map:
+		if field.value.Type().Key().Kind() != reflect.String || field.value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("the type (%s) is not supported", field.value.Type())
+		}
+		values := map[string]string{}
+		for _, pair := range splitList(defaultValue) {
+			parts := strings.SplitN(pair, "=", 2)
+			if len(parts) != 2 {
+				return fmt.Errorf("the default (%s) is not a list of key=value pairs", defaultValue)
+			}
+			values[parts[0]] = parts[1]
+		}
+		set.StringToString(name, values, usage)
+	default:
+		return fmt.Errorf("the type (%s) is not supported", field.value.Type())
+	}
+	return nil
+}
+
+func registerSliceFlag(set dependency.FlagSet, field boundField) error {
+	values := splitList(field.defaultValue)
+	switch field.value.Type().Elem().Kind() {
+	case reflect.String:
+		set.StringSlice(field.flag, values, field.usage)
+	case reflect.Int:
+		integers := make([]int, 0, len(values))
+		for _, value := range values {
+			integer, err := strconv.Atoi(value)
+			if err != nil {
+				return err
+			}
+			integers = append(integers, integer)
+		}
+		set.IntSlice(field.flag, integers, field.usage)
+	default:
+		return fmt.Errorf("the type (%s) is not supported", field.value.Type())
+	}
+	return nil
+}
+
+// Populate sets each field of the target struct with a flag tag to the value of the
+// flag, and then validates the values, returning a ValidationError if any are invalid
+func Populate(getter dependency.ConfigGetter, target interface{}) error {
+	value := reflect.ValueOf(target)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return fmt.Errorf("cannot populate (%T), it must be a pointer to a struct", target)
+	}
+	validationError := ValidationError{}
+	for _, field := range boundFields(value) {
+		if err := populateField(getter, field); err != nil {
+			return err
+		}
+		validationError.Fields = append(validationError.Fields, validateField(field)...)
+	}
+	if len(validationError.Fields) > 0 {
+		return validationError
+	}
+	return nil
+}
+
+// nolint: gocyclo
+func populateField(getter dependency.ConfigGetter, field boundField) error {
+	var value interface{}
+	fieldType := field.value.Type()
+	switch {
+	case fieldType == durationType:
+		value = getter.GetDuration(field.flag)
+	case fieldType.Kind() == reflect.String:
+		value = getter.GetString(field.flag)
+	case fieldType.Kind() == reflect.Bool:
+		value = getter.GetBool(field.flag)
+	case fieldType.Kind() == reflect.Int:
+		value = getter.GetInt(field.flag)
+	case fieldType.Kind() == reflect.Int32:
+		value = getter.GetInt32(field.flag)
+	case fieldType.Kind() == reflect.Int64:
+		value = getter.GetInt64(field.flag)
+	case fieldType.Kind() == reflect.Uint:
+		value = getter.GetUint(field.flag)
+	case fieldType.Kind() == reflect.Uint32:
+		value = getter.GetUint32(field.flag)
+	case fieldType.Kind() == reflect.Uint64:
+		value = getter.GetUint64(field.flag)
+	case fieldType.Kind() == reflect.Float64:
+		value = getter.GetFloat64(field.flag)
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.String:
+		value = getter.GetStringSlice(field.flag)
+	case fieldType.Kind() == reflect.Slice && fieldType.Elem().Kind() == reflect.Int:
+		value = getter.GetIntSlice(field.flag)
+	case fieldType.Kind() == reflect.Map:
+		value = getter.GetStringMapString(field.flag)
+	default:
+		return fmt.Errorf("cannot populate (%s), the type (%s) is not supported", field.flag, fieldType)
+	}
+	field.value.Set(reflect.ValueOf(value).Convert(fieldType))
+	return nil
+}
+
+// validateField checks the value of the field against the rules in the validate tag,
+// the supported rules are required, omitempty, min=, max= and oneof= with values
+// separated by |
+func validateField(field boundField) []FieldError {
+	errors := make([]FieldError, 0)
+	for _, reason := range CheckRules(field.value, field.validate) {
+		errors = append(errors, FieldError{Flag: field.flag, Reason: reason})
+	}
+	return errors
+}
+
+// CheckRules checks the value against the comma separated rules of a validate
+// tag, and returns the reasons that the value is not valid. When the rules have
+// omitempty a zero value is not checked against any of the rules.
+func CheckRules(value reflect.Value, rules string) []string {
+	if rules == "" {
+		return nil
+	}
+	split := strings.Split(rules, ",")
+	for _, rule := range split {
+		if strings.TrimSpace(rule) == "omitempty" && value.IsZero() {
+			return nil
+		}
+	}
+	var reasons []string
+	for _, rule := range split {
+		parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+		argument := ""
+		if len(parts) == 2 {
+			argument = parts[1]
+		}
+		if reason := checkRule(value, parts[0], argument); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
+
+func checkRule(value reflect.Value, rule, argument string) string {
+	switch rule {
+	case "omitempty":
+	case "required":
+		if value.IsZero() {
+			return "is required"
+		}
+	case "min", "max":
+		limit, err := parseLimit(value.Type(), argument)
+		if err != nil {
+			return fmt.Sprintf("has an invalid %s rule (%s)", rule, argument)
+		}
+		actual := measure(value)
+		if rule == "min" && actual < limit {
+			return fmt.Sprintf("must be at least %s", argument)
+		}
+		if rule == "max" && actual > limit {
+			return fmt.Sprintf("must be at most %s", argument)
+		}
+	case "oneof":
+		options := strings.Split(argument, "|")
+		actual := fmt.Sprint(value.Interface())
+		for _, option := range options {
+			if option == actual {
+				return ""
+			}
+		}
+		return fmt.Sprintf("must be one of (%s)", strings.Join(options, ", "))
+	default:
+		return fmt.Sprintf("has an unknown validation rule (%s)", rule)
+	}
+	return ""
+}
+
+// measure gives the size of the value that min and max rules are compared to, the
+// length of strings, slices and maps, otherwise the numeric value
+func measure(value reflect.Value) float64 {
+	switch value.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return float64(value.Len())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		return value.Float()
+	}
+	return 0
+}
+
+func parseLimit(valueType reflect.Type, argument string) (float64, error) {
+	if valueType == durationType {
+		duration, err := time.ParseDuration(argument)
+		return float64(duration), err
+	}
+	return strconv.ParseFloat(argument, 64)
+}
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func parseInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func parseUint(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func parseBool(value string) (bool, error) {
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
+
+func parseFloat(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+func splitList(value string) []string {
+	if value == "" {
+		return []string{}
+	}
+	values := strings.Split(value, ",")
+	for i := range values {
+		values[i] = strings.TrimSpace(values[i])
+	}
+	return values
+}
@@ -0,0 +1,168 @@
+package config_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+type retryConfig struct {
+	Attempts int           `flag:"retry-attempts" default:"3" usage:"The number of attempts" validate:"min=1,max=10"`
+	Backoff  time.Duration `flag:"retry-backoff" default:"1s" usage:"The backoff between attempts" validate:"max=1m"`
+}
+
+type boundConfig struct {
+	Name    string            `flag:"bound-name" default:"service" usage:"The name" validate:"required"`
+	Mode    string            `flag:"bound-mode" default:"fast" usage:"The mode" validate:"oneof=fast|slow"`
+	Enabled bool              `flag:"bound-enabled" default:"true" usage:"Whether it is enabled"`
+	Ratio   float64           `flag:"bound-ratio" default:"0.5" usage:"The ratio"`
+	Workers uint32            `flag:"bound-workers" default:"4" usage:"The number of workers"`
+	Hosts   []string          `flag:"bound-hosts" default:"a,b" usage:"The hosts" validate:"min=1"`
+	Ports   []int             `flag:"bound-ports" default:"80,443" usage:"The ports"`
+	Labels  map[string]string `flag:"bound-labels" default:"team=platform" usage:"The labels"`
+	Retry   retryConfig
+}
+
+func executeBound(t *testing.T, args ...string) dependency.ConfigGetter {
+	cmd := &cobra.Command{}
+	config.RegisterFlags(cmd.PersistentFlags(), boundConfig{})
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestPopulate(t *testing.T) {
+	cfg := executeBound(t, "--bound-name=api", "--retry-attempts=5")
+	got := boundConfig{}
+	if err := config.Populate(cfg, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := boundConfig{
+		Name:    "api",
+		Mode:    "fast",
+		Enabled: true,
+		Ratio:   0.5,
+		Workers: 4,
+		Hosts:   []string{"a", "b"},
+		Ports:   []int{80, 443},
+		Labels:  map[string]string{"team": "platform"},
+		Retry: retryConfig{
+			Attempts: 5,
+			Backoff:  time.Second,
+		},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected config to be (%+v), got (%+v)", expected, got)
+	}
+}
+
+func TestPopulateValidationFails(t *testing.T) {
+	cfg := executeBound(t, "--bound-name=", "--bound-mode=medium", "--retry-attempts=11", "--retry-backoff=2m")
+	err := config.Populate(cfg, &boundConfig{})
+	validationError := config.ValidationError{}
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a validation error, got (%v)", err)
+	}
+	expected := []config.FieldError{
+		{Flag: "bound-name", Reason: "is required"},
+		{Flag: "bound-mode", Reason: "must be one of (fast, slow)"},
+		{Flag: "retry-attempts", Reason: "must be at most 10"},
+		{Flag: "retry-backoff", Reason: "must be at most 1m"},
+	}
+	if !reflect.DeepEqual(expected, validationError.Fields) {
+		t.Fatalf("expected errors to be (%v), got (%v)", expected, validationError.Fields)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		rules    string
+		expected []string
+	}{
+		{value: 0, rules: "min=1,max=65535", expected: []string{"must be at least 1"}},
+		{value: "", rules: "oneof=fast|slow", expected: []string{"must be one of (fast, slow)"}},
+		{value: 0, rules: "omitempty,min=1,max=65535"},
+		{value: "", rules: "omitempty,oneof=fast|slow"},
+		{value: 70000, rules: "omitempty,min=1,max=65535", expected: []string{"must be at most 65535"}},
+		{value: "medium", rules: "omitempty,oneof=fast|slow", expected: []string{"must be one of (fast, slow)"}},
+	}
+	for _, test := range tests {
+		reasons := config.CheckRules(reflect.ValueOf(test.value), test.rules)
+		if !reflect.DeepEqual(test.expected, reasons) {
+			t.Errorf("expected (%#v) with rules (%s) to have the reasons (%v), got (%v)", test.value, test.rules, test.expected, reasons)
+		}
+	}
+}
+
+func TestPopulateRequiresPointer(t *testing.T) {
+	if err := config.Populate(executeBound(t), boundConfig{}); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func TestRegisterFlagsPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic, got none")
+		}
+	}()
+	config.RegisterFlags((&cobra.Command{}).PersistentFlags(), struct {
+		Port int `flag:"port" default:"eighty"`
+	}{})
+}
+
+func TestBind(t *testing.T) {
+	cmd := &cobra.Command{}
+	var got retryConfig
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(config.Bind(&retryConfig{})).
+		WithInvoke(func(cfg retryConfig) {
+			got = cfg
+		}).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{"--retry-attempts=2"})
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		builder.BuildTest(t).RequireStart().RequireStop()
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	expected := retryConfig{Attempts: 2, Backoff: time.Second}
+	if got != expected {
+		t.Fatalf("expected config to be (%+v), got (%+v)", expected, got)
+	}
+}
+
+func TestBindFails(t *testing.T) {
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(config.Bind(retryConfig{})).
+		WithInvoke(func(cfg retryConfig) {}).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{"--retry-attempts=0"})
+	var err error
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		err = builder.Build().Err()
+	}
+	if executeErr := cmd.Execute(); executeErr != nil {
+		t.Fatal(executeErr)
+	}
+	if err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/BlackBX/service-framework/dependency"
@@ -11,15 +13,55 @@ import (
 	"go.uber.org/fx"
 )
 
+// ConfigEnvVar is the environment variable that can be used to set the
+// configuration files to load when the config flag has not been set
+const ConfigEnvVar = "SERVICE_CONFIG"
+
 // Service provides the config framework as a *viper.Viper and a
-// framework.ConfigGetter
+// framework.ConfigGetter, values that reference secrets, such as
+// file:///run/secrets/password, are resolved by the SecretResolvers
+// in the "secret-resolvers" group. The keys read from the framework.ConfigGetter
+// are recorded, and checked against the registered flags when config-strict is set.
 var Service = dependency.Service{
+	Name: "config",
+	ConfigFunc: func(set dependency.FlagSet) {
+		set.StringSlice(
+			"config",
+			nil,
+			fmt.Sprintf("Configuration files (YAML/TOML/JSON) to load in order, can also be set with %s", ConfigEnvVar),
+		)
+		set.Bool("config-watch", false, "Whether to reload the configuration when the configuration files change")
+		set.Bool("config-reload-signal", false, "Whether to reload the configuration when the process receives SIGHUP")
+		set.String(
+			"config-strict",
+			StrictOff,
+			fmt.Sprintf("Whether to %s or %s when configuration keys that are not registered flags are read", StrictWarn, StrictFail),
+		)
+	},
 	Dependencies: fx.Provide(
 		NewFactory().Configure,
+		NewFactory().FlagDescriber,
+		NewServiceStore,
+		NewWatcher,
+		NewKeyRecorder,
+		fx.Annotated{
+			Group:  "secret-resolvers",
+			Target: NewFileSecretResolver,
+		},
+		fx.Annotated{
+			Group:  "secret-resolvers",
+			Target: NewEnvFileSecretResolver,
+		},
 	),
-	Constructor: func(config *viper.Viper) dependency.ConfigGetter {
-		return config
-	},
+	InvokeFunc:  Invoke,
+	Constructor: NewConfigGetter,
+}
+
+// Invoke watches the configuration for changes, and checks the keys that have
+// been read from the configuration
+func Invoke(watch WatchParams, strict StrictParams) {
+	Watch(watch)
+	CheckKeys(strict)
 }
 
 // Viper is an interface that the *viper.Viper type adheres to, this is
@@ -28,6 +70,10 @@ type Viper interface {
 	AutomaticEnv()
 	SetEnvKeyReplacer(r *strings.Replacer)
 	BindPFlags(flags *pflag.FlagSet) error
+	SetConfigFile(in string)
+	ReadInConfig() error
+	MergeInConfig() error
+	GetString(key string) string
 }
 
 // NewFactory gives a new instance of the Factory type with a string.Replacer
@@ -57,8 +103,29 @@ func (f Factory) Configure(cmd *cobra.Command) (*viper.Viper, error) {
 	return config, nil
 }
 
-// ConfigureViper is a function that will configure viper
+// Load will produce a new instance of the *viper.Viper type configured like
+// ConfigureViper, with the given configuration files
+func (f Factory) Load(cmd *cobra.Command, files []string) (*viper.Viper, error) {
+	config := viper.New()
+	if err := bindViper(config, cmd, f.Replacer); err != nil {
+		return nil, err
+	}
+	if err := LoadConfigFiles(config, files); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// ConfigureViper is a function that will configure viper, values are taken from
+// flags, then environment variables, then configuration files, then the flag defaults
 func ConfigureViper(config Viper, cmd *cobra.Command, replacer *strings.Replacer) error {
+	if err := bindViper(config, cmd, replacer); err != nil {
+		return err
+	}
+	return LoadConfigFiles(config, ConfigFiles(cmd))
+}
+
+func bindViper(config Viper, cmd *cobra.Command, replacer *strings.Replacer) error {
 	config.AutomaticEnv()
 	config.SetEnvKeyReplacer(replacer)
 	if err := config.BindPFlags(cmd.Flags()); err != nil {
@@ -66,3 +133,104 @@ func ConfigureViper(config Viper, cmd *cobra.Command, replacer *strings.Replacer
 	}
 	return nil
 }
+
+// ConfigFiles returns the configuration files set by the config flag, or
+// the SERVICE_CONFIG environment variable if the flag has not been set, the
+// files of the environment variable are separated by commas, and may be padded
+// with spaces
+func ConfigFiles(cmd *cobra.Command) []string {
+	if flag := cmd.Flags().Lookup("config"); flag != nil && flag.Changed {
+		files, err := cmd.Flags().GetStringSlice("config")
+		if err == nil {
+			return files
+		}
+	}
+	files := make([]string, 0)
+	for _, file := range strings.Split(os.Getenv(ConfigEnvVar), ",") {
+		if file = strings.TrimSpace(file); file != "" {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+	return files
+}
+
+// LoadConfigFiles merges the given configuration files into viper in order, after
+// each file the overlay for the environment is merged if it exists, for example
+// config.yaml would be followed by config.production.yaml. Any configuration
+// previously loaded from files is replaced.
+func LoadConfigFiles(config Viper, files []string) error {
+	for i, file := range files {
+		config.SetConfigFile(file)
+		read := config.MergeInConfig
+		if i == 0 {
+			read = config.ReadInConfig
+		}
+		if err := read(); err != nil {
+			return fmt.Errorf("could not load config file (%s), got error (%w)", file, err)
+		}
+		overlay := EnvironmentOverlay(file, config.GetString("environment"))
+		if overlay == "" {
+			continue
+		}
+		if _, err := os.Stat(overlay); err != nil {
+			continue
+		}
+		config.SetConfigFile(overlay)
+		if err := config.MergeInConfig(); err != nil {
+			return fmt.Errorf("could not load config file (%s), got error (%w)", overlay, err)
+		}
+	}
+	return nil
+}
+
+// EnvironmentOverlay returns the name of the overlay of the given configuration
+// file for the given environment
+func EnvironmentOverlay(file, environment string) string {
+	if environment == "" {
+		return ""
+	}
+	extension := filepath.Ext(file)
+	return fmt.Sprintf("%s.%s%s", strings.TrimSuffix(file, extension), environment, extension)
+}
+
+// FlagDescriber creates a dependency.FlagDescriber that describes flags using the
+// values held by the *viper.Viper
+func (f Factory) FlagDescriber(config *viper.Viper) dependency.FlagDescriber {
+	return FlagDescriber{
+		Config:   config,
+		Replacer: f.Replacer,
+	}
+}
+
+// FlagDescriber describes the effective value of a flag, and whether it was set by
+// a flag, an environment variable, a configuration file or the default value
+type FlagDescriber struct {
+	Config   *viper.Viper
+	Replacer *strings.Replacer
+}
+
+// DescribeFlag returns the effective value of the flag, and the source of the value
+func (d FlagDescriber) DescribeFlag(flag *pflag.Flag) (value, source string) {
+	switch {
+	case flag.Changed:
+		source = "flag"
+	case d.envSet(flag.Name):
+		source = "env"
+	case d.Config.InConfig(flag.Name):
+		source = "file"
+	default:
+		source = "default"
+	}
+	if strings.HasSuffix(flag.Value.Type(), "Slice") || strings.HasSuffix(flag.Value.Type(), "Array") {
+		return strings.Join(d.Config.GetStringSlice(flag.Name), ","), source
+	}
+	return d.Config.GetString(flag.Name), source
+}
+
+func (d FlagDescriber) envSet(key string) bool {
+	_, ok := os.LookupEnv(strings.ToUpper(d.Replacer.Replace(key)))
+	return ok
+}
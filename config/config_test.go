@@ -2,6 +2,8 @@ package config_test
 
 import (
 	"errors"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -20,6 +22,20 @@ func (f failingViper) BindPFlags(flags *pflag.FlagSet) error {
 	return errors.New("an error")
 }
 
+func (f failingViper) SetConfigFile(_ string) {}
+
+func (f failingViper) ReadInConfig() error {
+	return errors.New("an error")
+}
+
+func (f failingViper) MergeInConfig() error {
+	return errors.New("an error")
+}
+
+func (f failingViper) GetString(_ string) string {
+	return ""
+}
+
 func TestFactory_ConfigureSucceeds(t *testing.T) {
 	_, err := config.NewFactory().Configure(&cobra.Command{})
 	if err != nil {
@@ -46,3 +62,140 @@ func TestConfigureViperFails(t *testing.T) {
 		t.Fatal("expected an error, got none")
 	}
 }
+
+func TestFlagDescriber_DescribeFlag(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("from-default", "default", "")
+	cmd.Flags().String("from-env", "default", "")
+	cmd.Flags().String("from-flag", "default", "")
+	cmd.Flags().StringSlice("from-slice", []string{"foo", "bar"}, "")
+	if err := cmd.Flags().Set("from-flag", "flag"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv("FROM_ENV", "env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("FROM_ENV")
+	factory := config.NewFactory()
+	cfg, err := factory.Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	describer := factory.FlagDescriber(cfg)
+	tests := []struct {
+		flag           string
+		expectedValue  string
+		expectedSource string
+	}{
+		{flag: "from-default", expectedValue: "default", expectedSource: "default"},
+		{flag: "from-env", expectedValue: "env", expectedSource: "env"},
+		{flag: "from-flag", expectedValue: "flag", expectedSource: "flag"},
+		{flag: "from-slice", expectedValue: "foo,bar", expectedSource: "default"},
+	}
+	for _, test := range tests {
+		t.Run(test.flag, func(t *testing.T) {
+			value, source := describer.DescribeFlag(cmd.Flags().Lookup(test.flag))
+			if value != test.expectedValue {
+				t.Errorf("expected value (%s), got (%s)", test.expectedValue, value)
+			}
+			if source != test.expectedSource {
+				t.Errorf("expected source (%s), got (%s)", test.expectedSource, source)
+			}
+		})
+	}
+}
+
+func TestConfigureViperPrecedence(t *testing.T) {
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	cmd.Flags().String("environment", "production", "")
+	for _, key := range []string{"from-default", "from-file", "from-env", "from-flag", "from-overlay", "from-toml", "from-json"} {
+		cmd.Flags().String(key, "default", "")
+	}
+	if err := cmd.Flags().Set("from-flag", "flag"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Flags().Set("config", "testdata/config.yaml,testdata/extra.toml"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Setenv(config.ConfigEnvVar, "testdata/extra.json"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(config.ConfigEnvVar)
+	if err := os.Setenv("FROM_ENV", "env"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("FROM_ENV")
+
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	expectedValues := map[string]string{
+		"from-default": "default",
+		"from-file":    "file",
+		"from-env":     "env",
+		"from-flag":    "flag",
+		"from-overlay": "overlay",
+		"from-toml":    "toml",
+		"from-json":    "default",
+	}
+	for key, expectedValue := range expectedValues {
+		if value := cfg.GetString(key); value != expectedValue {
+			t.Errorf("expected (%s) to be (%s), got (%s)", key, expectedValue, value)
+		}
+	}
+}
+
+func TestConfigureViperConfigEnvVar(t *testing.T) {
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	cmd.Flags().String("from-json", "default", "")
+	if err := os.Setenv(config.ConfigEnvVar, "testdata/extra.json"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(config.ConfigEnvVar)
+
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if value := cfg.GetString("from-json"); value != "json" {
+		t.Fatalf("expected (json), got (%s)", value)
+	}
+}
+
+func TestConfigFilesTrimsConfigEnvVar(t *testing.T) {
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	if err := os.Setenv(config.ConfigEnvVar, " testdata/base.yaml , ,testdata/extra.json, "); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv(config.ConfigEnvVar)
+
+	expected := []string{"testdata/base.yaml", "testdata/extra.json"}
+	if files := config.ConfigFiles(cmd); !reflect.DeepEqual(expected, files) {
+		t.Fatalf("expected the files (%q), got (%q)", expected, files)
+	}
+}
+
+func TestConfigureViperMissingFile(t *testing.T) {
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	if err := cmd.Flags().Set("config", "testdata/missing.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := config.NewFactory().Configure(cmd); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func TestEnvironmentOverlay(t *testing.T) {
+	expected := "testdata/config.production.yaml"
+	if got := config.EnvironmentOverlay("testdata/config.yaml", "production"); got != expected {
+		t.Fatalf("expected (%s), got (%s)", expected, got)
+	}
+	if got := config.EnvironmentOverlay("testdata/config.yaml", ""); got != "" {
+		t.Fatalf("expected no overlay, got (%s)", got)
+	}
+}
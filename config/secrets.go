@@ -0,0 +1,137 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// SecretResolverFunc is a function that resolves a reference to a secret
+// into the value of the secret
+type SecretResolverFunc func(reference *url.URL) (string, error)
+
+// SecretResolver resolves configuration values that are references to secrets
+// with the given URL scheme, such as file:///run/secrets/password. Resolvers
+// are provided to the application in the "secret-resolvers" group.
+type SecretResolver struct {
+	Scheme  string
+	Resolve SecretResolverFunc
+}
+
+// NewFileSecretResolver creates a SecretResolver that reads the secret from
+// a file, for example file:///run/secrets/password
+func NewFileSecretResolver() SecretResolver {
+	return SecretResolver{
+		Scheme: "file",
+		Resolve: func(reference *url.URL) (string, error) {
+			path := referencePath(reference)
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("could not read secret file (%s), got error (%w)", path, err)
+			}
+			return strings.TrimRight(string(contents), "\r\n"), nil
+		},
+	}
+}
+
+// NewEnvFileSecretResolver creates a SecretResolver that reads the secret from
+// a file of KEY=value lines, the key is given as the fragment of the reference,
+// for example env-file:///run/secrets/app.env#POSTGRES_PASSWORD
+func NewEnvFileSecretResolver() SecretResolver {
+	return SecretResolver{
+		Scheme: "env-file",
+		Resolve: func(reference *url.URL) (string, error) {
+			path := referencePath(reference)
+			if reference.Fragment == "" {
+				return "", fmt.Errorf("the env file reference (%s) does not have a key", path)
+			}
+			contents, err := ioutil.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("could not read secret env file (%s), got error (%w)", path, err)
+			}
+			scanner := bufio.NewScanner(bytes.NewReader(contents))
+			for scanner.Scan() {
+				line := strings.TrimSpace(scanner.Text())
+				if line == "" || strings.HasPrefix(line, "#") {
+					continue
+				}
+				line = strings.TrimPrefix(line, "export ")
+				parts := strings.SplitN(line, "=", 2)
+				if len(parts) != 2 || strings.TrimSpace(parts[0]) != reference.Fragment {
+					continue
+				}
+				return strings.Trim(strings.TrimSpace(parts[1]), `"'`), nil
+			}
+			return "", fmt.Errorf("the key (%s) was not found in the env file (%s)", reference.Fragment, path)
+		},
+	}
+}
+
+// referencePath gives the path of a file reference, allowing relative paths
+// such as file://testdata/secret as well as absolute paths
+func referencePath(reference *url.URL) string {
+	return reference.Host + reference.Path
+}
+
+// SecretParams are the dependencies required to get the configuration
+type SecretParams struct {
+	fx.In
+
+	Store    *Store
+	Recorder *KeyRecorder `optional:"true"`
+}
+
+// NewConfigGetter gives you the configuration held by the *Store, with the secrets
+// that it references resolved, as a dependency.ConfigGetter, which records the
+// keys read from it when a *KeyRecorder is provided and config-strict is not off
+func NewConfigGetter(params SecretParams) dependency.ConfigGetter {
+	if params.Recorder != nil && strictMode(params.Store) != StrictOff {
+		return params.Recorder.Getter(params.Store)
+	}
+	return params.Store
+}
+
+// ResolveSecrets resolves the values of any keys in the configuration that are
+// references to secrets, and returns the values of the secrets by key, the
+// configuration is not changed. A value is a reference if it is a URL with the
+// scheme of one of the resolvers.
+func ResolveSecrets(config *viper.Viper, resolvers ...SecretResolver) (map[string]string, error) {
+	secrets := map[string]string{}
+	if len(resolvers) == 0 {
+		return secrets, nil
+	}
+	resolverFuncs := make(map[string]SecretResolverFunc, len(resolvers))
+	for _, resolver := range resolvers {
+		resolverFuncs[resolver.Scheme] = resolver.Resolve
+	}
+	keys := config.AllKeys()
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := config.Get(key).(string)
+		if !ok || !strings.Contains(value, "://") {
+			continue
+		}
+		reference, err := url.Parse(value)
+		if err != nil {
+			continue
+		}
+		resolve, ok := resolverFuncs[reference.Scheme]
+		if !ok {
+			continue
+		}
+		secret, err := resolve(reference)
+		if err != nil {
+			return nil, fmt.Errorf("could not resolve the secret for (%s), got error (%w)", key, err)
+		}
+		secrets[key] = secret
+	}
+	return secrets, nil
+}
@@ -0,0 +1,93 @@
+package config_test
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/spf13/cobra"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.Flags().String("from-file", "file://testdata/secret", "")
+	cmd.Flags().String("from-env-file", "env-file://testdata/secrets.env#REDIS_PASSWORD", "")
+	cmd.Flags().String("from-env", "", "")
+	cmd.Flags().String("plain", "https://example.com", "")
+	if err := os.Setenv("FROM_ENV", "env-file://testdata/secrets.env#POSTGRES_PASSWORD"); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Unsetenv("FROM_ENV")
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := config.NewStore(cfg, config.NewFileSecretResolver(), config.NewEnvFileSecretResolver())
+	if err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	expectedValues := map[string]string{
+		"from-file":     "hunter2",
+		"from-env-file": "hunter3",
+		"from-env":      "hunter2",
+		"plain":         "https://example.com",
+	}
+	for key, expectedValue := range expectedValues {
+		if value := store.GetString(key); value != expectedValue {
+			t.Errorf("expected (%s) to be (%s), got (%s)", key, expectedValue, value)
+		}
+	}
+	if value := cfg.GetString("from-file"); value != "file://testdata/secret" {
+		t.Errorf("expected the configuration to keep the reference, got (%s)", value)
+	}
+}
+
+func TestResolveSecretsFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		resolver config.SecretResolver
+	}{
+		{
+			name:     "missing file",
+			value:    "file://testdata/missing",
+			resolver: config.NewFileSecretResolver(),
+		},
+		{
+			name:     "missing env file key",
+			value:    "env-file://testdata/secrets.env#MISSING",
+			resolver: config.NewEnvFileSecretResolver(),
+		},
+		{
+			name:     "env file without key",
+			value:    "env-file://testdata/secrets.env",
+			resolver: config.NewEnvFileSecretResolver(),
+		},
+		{
+			name:  "failing resolver",
+			value: "custom:///secret",
+			resolver: config.SecretResolver{
+				Scheme: "custom",
+				Resolve: func(reference *url.URL) (string, error) {
+					return "", errors.New("an error")
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			cmd.Flags().String("secret", test.value, "")
+			cfg, err := config.NewFactory().Configure(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := config.ResolveSecrets(cfg, test.resolver); err == nil {
+				t.Fatal("expected an error, got none")
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package config
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+)
+
+// NewStore creates a new instance of the *Store type holding the configuration,
+// the secrets referenced by the configuration are resolved by the resolvers
+func NewStore(config *viper.Viper, resolvers ...SecretResolver) (*Store, error) {
+	store := &Store{resolvers: resolvers}
+	if err := store.Replace(config); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// StoreParams are the dependencies required to create the *Store
+type StoreParams struct {
+	fx.In
+
+	Config    *viper.Viper
+	Resolvers []SecretResolver `group:"secret-resolvers"`
+}
+
+// NewServiceStore creates the *Store of the Service from the configuration and
+// the SecretResolvers in the "secret-resolvers" group
+func NewServiceStore(params StoreParams) (*Store, error) {
+	return NewStore(params.Config, params.Resolvers...)
+}
+
+// Store holds the configuration, which is replaced as a whole when it is
+// reloaded. It is a dependency.ConfigGetter that reads from the configuration
+// held at the time of the read, so it is safe to read from while it is reloaded.
+// The values of keys that reference secrets are the values of the secrets, the
+// *viper.Viper keeps the references, and must not be changed once it is held.
+type Store struct {
+	current   atomic.Value
+	resolvers []SecretResolver
+}
+
+// snapshot is the configuration held by the Store, with its resolved secrets
+type snapshot struct {
+	config  *viper.Viper
+	secrets map[string]string
+}
+
+// get returns the value of the key, or the value of the secret it references
+func (s snapshot) get(key string) interface{} {
+	if secret, ok := s.secrets[strings.ToLower(key)]; ok {
+		return secret
+	}
+	return s.config.Get(key)
+}
+
+// values returns the values of the keys
+func (s snapshot) values(keys []string) map[string]interface{} {
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		values[key] = s.get(key)
+	}
+	return values
+}
+
+// Replace resolves the secrets referenced by the configuration, and replaces the
+// configuration held by the Store with it. When the secrets cannot be resolved
+// the configuration held by the Store is kept.
+func (s *Store) Replace(config *viper.Viper) error {
+	secrets, err := ResolveSecrets(config, s.resolvers...)
+	if err != nil {
+		return err
+	}
+	s.current.Store(snapshot{config: config, secrets: secrets})
+	return nil
+}
+
+// Viper returns the configuration held by the Store, which holds the references
+// to secrets rather than their values
+func (s *Store) Viper() *viper.Viper {
+	return s.snapshot().config
+}
+
+func (s *Store) snapshot() snapshot {
+	return s.current.Load().(snapshot)
+}
+
+// Get returns the value of the key
+func (s *Store) Get(key string) interface{} {
+	return s.snapshot().get(key)
+}
+
+// GetString returns the value of the key as a string
+func (s *Store) GetString(key string) string {
+	return cast.ToString(s.Get(key))
+}
+
+// GetBool returns the value of the key as a bool
+func (s *Store) GetBool(key string) bool {
+	return cast.ToBool(s.Get(key))
+}
+
+// GetInt returns the value of the key as an int
+func (s *Store) GetInt(key string) int {
+	return cast.ToInt(s.Get(key))
+}
+
+// GetInt32 returns the value of the key as an int32
+func (s *Store) GetInt32(key string) int32 {
+	return cast.ToInt32(s.Get(key))
+}
+
+// GetInt64 returns the value of the key as an int64
+func (s *Store) GetInt64(key string) int64 {
+	return cast.ToInt64(s.Get(key))
+}
+
+// GetUint returns the value of the key as a uint
+func (s *Store) GetUint(key string) uint {
+	return cast.ToUint(s.Get(key))
+}
+
+// GetUint32 returns the value of the key as a uint32
+func (s *Store) GetUint32(key string) uint32 {
+	return cast.ToUint32(s.Get(key))
+}
+
+// GetUint64 returns the value of the key as a uint64
+func (s *Store) GetUint64(key string) uint64 {
+	return cast.ToUint64(s.Get(key))
+}
+
+// GetFloat64 returns the value of the key as a float64
+func (s *Store) GetFloat64(key string) float64 {
+	return cast.ToFloat64(s.Get(key))
+}
+
+// GetTime returns the value of the key as a time.Time
+func (s *Store) GetTime(key string) time.Time {
+	return cast.ToTime(s.Get(key))
+}
+
+// GetDuration returns the value of the key as a time.Duration
+func (s *Store) GetDuration(key string) time.Duration {
+	return cast.ToDuration(s.Get(key))
+}
+
+// GetIntSlice returns the value of the key as a slice of ints
+func (s *Store) GetIntSlice(key string) []int {
+	return cast.ToIntSlice(s.Get(key))
+}
+
+// GetStringSlice returns the value of the key as a slice of strings
+func (s *Store) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(s.Get(key))
+}
+
+// GetStringMap returns the value of the key as a map of interfaces
+func (s *Store) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(s.Get(key))
+}
+
+// GetStringMapString returns the value of the key as a map of strings
+func (s *Store) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(s.Get(key))
+}
+
+// GetStringMapStringSlice returns the value of the key as a map of string slices
+func (s *Store) GetStringMapStringSlice(key string) map[string][]string {
+	return cast.ToStringMapStringSlice(s.Get(key))
+}
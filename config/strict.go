@@ -0,0 +1,228 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// The modes of the config-strict flag
+const (
+	StrictOff  = "off"
+	StrictWarn = "warn"
+	StrictFail = "fail"
+)
+
+// NewKeyRecorder creates a new instance of the *KeyRecorder type that checks
+// keys against the flags of the given command
+func NewKeyRecorder(cmd *cobra.Command) *KeyRecorder {
+	return &KeyRecorder{
+		Cmd:  cmd,
+		keys: map[string]struct{}{},
+	}
+}
+
+// KeyRecorder records the keys that are read from the configuration, so that
+// keys that have not been registered as flags can be reported
+type KeyRecorder struct {
+	Cmd   *cobra.Command
+	mutex sync.Mutex
+	keys  map[string]struct{}
+}
+
+// Getter wraps the dependency.ConfigGetter, so that the keys read from it are
+// recorded by the KeyRecorder
+func (r *KeyRecorder) Getter(getter dependency.ConfigGetter) dependency.ConfigGetter {
+	return recordingGetter{
+		getter:   getter,
+		recorder: r,
+	}
+}
+
+func (r *KeyRecorder) record(key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.keys[key] = struct{}{}
+}
+
+// Keys returns the keys that have been read, in alphabetical order
+func (r *KeyRecorder) Keys() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	keys := make([]string, 0, len(r.keys))
+	for key := range r.keys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Unregistered returns the keys that have been read, but have not been
+// registered as flags on the command
+func (r *KeyRecorder) Unregistered() []string {
+	unregistered := make([]string, 0)
+	for _, key := range r.Keys() {
+		if !r.registered(key) {
+			unregistered = append(unregistered, key)
+		}
+	}
+	return unregistered
+}
+
+func (r *KeyRecorder) registered(key string) bool {
+	return r.Cmd.Flags().Lookup(key) != nil ||
+		r.Cmd.PersistentFlags().Lookup(key) != nil ||
+		r.Cmd.InheritedFlags().Lookup(key) != nil
+}
+
+// Err returns an error listing the unregistered keys, if any have been read
+func (r *KeyRecorder) Err() error {
+	unregistered := r.Unregistered()
+	if len(unregistered) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"command (%s) read configuration keys that are not registered as flags (%s)",
+		r.Cmd.Name(),
+		strings.Join(unregistered, ", "),
+	)
+}
+
+// strictMode returns the mode of the config-strict flag, which is off when unset
+func strictMode(getter dependency.ConfigGetter) string {
+	if mode := getter.GetString("config-strict"); mode != "" {
+		return mode
+	}
+	return StrictOff
+}
+
+// StrictParams are the dependencies required to check the keys read from the configuration
+type StrictParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    *viper.Viper
+	Recorder  *KeyRecorder
+	Logger    *zap.Logger `optional:"true"`
+}
+
+// CheckKeys checks the keys read from the configuration once all of the services
+// have been constructed, if config-strict is warn the unregistered keys are logged,
+// and if it is fail the application will fail to start
+func CheckKeys(params StrictParams) {
+	mode := strictMode(params.Config)
+	if mode == StrictOff {
+		return
+	}
+	logger := params.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			err := params.Recorder.Err()
+			switch {
+			case err == nil:
+				return nil
+			case mode == StrictFail:
+				return err
+			}
+			logger.Warn("Unregistered configuration keys were read", zap.Strings("keys", params.Recorder.Unregistered()))
+			return nil
+		},
+	})
+}
+
+type recordingGetter struct {
+	getter   dependency.ConfigGetter
+	recorder *KeyRecorder
+}
+
+func (g recordingGetter) GetString(key string) string {
+	g.recorder.record(key)
+	return g.getter.GetString(key)
+}
+
+func (g recordingGetter) GetBool(key string) bool {
+	g.recorder.record(key)
+	return g.getter.GetBool(key)
+}
+
+func (g recordingGetter) GetInt(key string) int {
+	g.recorder.record(key)
+	return g.getter.GetInt(key)
+}
+
+func (g recordingGetter) GetInt32(key string) int32 {
+	g.recorder.record(key)
+	return g.getter.GetInt32(key)
+}
+
+func (g recordingGetter) GetInt64(key string) int64 {
+	g.recorder.record(key)
+	return g.getter.GetInt64(key)
+}
+
+func (g recordingGetter) GetUint(key string) uint {
+	g.recorder.record(key)
+	return g.getter.GetUint(key)
+}
+
+func (g recordingGetter) GetUint32(key string) uint32 {
+	g.recorder.record(key)
+	return g.getter.GetUint32(key)
+}
+
+func (g recordingGetter) GetUint64(key string) uint64 {
+	g.recorder.record(key)
+	return g.getter.GetUint64(key)
+}
+
+func (g recordingGetter) GetFloat64(key string) float64 {
+	g.recorder.record(key)
+	return g.getter.GetFloat64(key)
+}
+
+func (g recordingGetter) GetTime(key string) time.Time {
+	g.recorder.record(key)
+	return g.getter.GetTime(key)
+}
+
+func (g recordingGetter) GetDuration(key string) time.Duration {
+	g.recorder.record(key)
+	return g.getter.GetDuration(key)
+}
+
+func (g recordingGetter) GetIntSlice(key string) []int {
+	g.recorder.record(key)
+	return g.getter.GetIntSlice(key)
+}
+
+func (g recordingGetter) GetStringSlice(key string) []string {
+	g.recorder.record(key)
+	return g.getter.GetStringSlice(key)
+}
+
+func (g recordingGetter) GetStringMap(key string) map[string]interface{} {
+	g.recorder.record(key)
+	return g.getter.GetStringMap(key)
+}
+
+func (g recordingGetter) GetStringMapString(key string) map[string]string {
+	g.recorder.record(key)
+	return g.getter.GetStringMapString(key)
+}
+
+func (g recordingGetter) GetStringMapStringSlice(key string) map[string][]string {
+	g.recorder.record(key)
+	return g.getter.GetStringMapStringSlice(key)
+}
@@ -0,0 +1,123 @@
+package config_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestKeyRecorder(t *testing.T) {
+	cmd := &cobra.Command{}
+	cmd.PersistentFlags().String("registered", "", "")
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := config.NewKeyRecorder(cmd)
+	getter := recorder.Getter(cfg)
+	getter.GetString("registered")
+	getter.GetDuration("unregistered")
+	if expected := []string{"registered", "unregistered"}; !reflect.DeepEqual(expected, recorder.Keys()) {
+		t.Fatalf("expected keys to be (%v), got (%v)", expected, recorder.Keys())
+	}
+	if expected := []string{"unregistered"}; !reflect.DeepEqual(expected, recorder.Unregistered()) {
+		t.Fatalf("expected unregistered keys to be (%v), got (%v)", expected, recorder.Unregistered())
+	}
+	if recorder.Err() == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func TestCheckKeys(t *testing.T) {
+	tests := []struct {
+		name        string
+		mode        string
+		expectError bool
+		expectWarn  bool
+	}{
+		{
+			name: "off",
+			mode: config.StrictOff,
+		},
+		{
+			name:       "warn",
+			mode:       config.StrictWarn,
+			expectWarn: true,
+		},
+		{
+			name:        "fail",
+			mode:        config.StrictFail,
+			expectError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			core, logs := observer.New(zap.WarnLevel)
+			cmd := &cobra.Command{}
+			builder := dependency.NewBuilder(cmd).
+				WithService(config.Service).
+				WithConstructor(func() *zap.Logger {
+					return zap.New(core)
+				}).
+				WithInvoke(func(getter dependency.ConfigGetter) {
+					getter.GetString("not-a-flag")
+				}).
+				WithModule(fx.NopLogger)
+			cmd.SetArgs([]string{"--config-strict=" + test.mode})
+			var err error
+			cmd.Run = func(cmd *cobra.Command, args []string) {
+				app := builder.Build()
+				err = app.Start(context.Background())
+				if err == nil {
+					err = app.Stop(context.Background())
+				}
+			}
+			if executeErr := cmd.Execute(); executeErr != nil {
+				t.Fatal(executeErr)
+			}
+			if (err != nil) != test.expectError {
+				t.Fatalf("expected error (%t), got (%v)", test.expectError, err)
+			}
+			if (logs.Len() > 0) != test.expectWarn {
+				t.Fatalf("expected warning (%t), got (%d) logs", test.expectWarn, logs.Len())
+			}
+		})
+	}
+}
+
+func TestNewConfigGetterRecordsKeys(t *testing.T) {
+	for mode, expectedKeys := range map[string][]string{
+		config.StrictOff:  {},
+		config.StrictWarn: {"registered"},
+	} {
+		t.Run(mode, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			config.Service.ConfigFunc(cmd.Flags())
+			cmd.Flags().String("registered", "", "")
+			if err := cmd.Flags().Set("config-strict", mode); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := config.NewFactory().Configure(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store, err := config.NewStore(cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recorder := config.NewKeyRecorder(cmd)
+			getter := config.NewConfigGetter(config.SecretParams{Store: store, Recorder: recorder})
+			getter.GetString("registered")
+			if keys := recorder.Keys(); !reflect.DeepEqual(expectedKeys, keys) {
+				t.Fatalf("expected keys to be (%v), got (%v)", expectedKeys, keys)
+			}
+		})
+	}
+}
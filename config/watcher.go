@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// Subscriber is a function that is called with the configuration when the
+// values of the keys that it subscribed to change
+type Subscriber func(config dependency.ConfigGetter)
+
+type subscription struct {
+	keys       []string
+	subscriber Subscriber
+}
+
+// NewWatcher creates a new instance of the *Watcher type that reloads the
+// configuration files of the command into the store
+func NewWatcher(store *Store, cmd *cobra.Command) *Watcher {
+	return &Watcher{
+		Store: store,
+		Files: ConfigFiles(cmd),
+		Load: func(files []string) (*viper.Viper, error) {
+			return NewFactory().Load(cmd, files)
+		},
+	}
+}
+
+// Watcher reloads the configuration files, and notifies its subscribers when
+// the values of the keys that they have subscribed to change. The configuration
+// is loaded into a new *viper.Viper, which replaces the configuration held by
+// the Store once it has been loaded, so a reload that fails changes nothing.
+type Watcher struct {
+	Store         *Store
+	Files         []string
+	Load          func(files []string) (*viper.Viper, error)
+	mutex         sync.Mutex
+	subscriptions []subscription
+}
+
+// Subscribe registers the subscriber to be called when the value of any of
+// the given keys changes after the configuration is reloaded
+func (w *Watcher) Subscribe(subscriber Subscriber, keys ...string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.subscriptions = append(w.subscriptions, subscription{
+		keys:       keys,
+		subscriber: subscriber,
+	})
+}
+
+// Reload reloads the configuration files, and calls the subscribers of any
+// keys whose values have changed
+func (w *Watcher) Reload() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	config, err := w.Load(w.Files)
+	if err != nil {
+		return err
+	}
+	previous := w.Store.snapshot()
+	if err := w.Store.Replace(config); err != nil {
+		return err
+	}
+	current := w.Store.snapshot()
+	for _, subscription := range w.subscriptions {
+		if reflect.DeepEqual(previous.values(subscription.keys), current.values(subscription.keys)) {
+			continue
+		}
+		subscription.subscriber(w.Store)
+	}
+	return nil
+}
+
+// WatchParams are the dependencies required to watch the configuration for changes
+type WatchParams struct {
+	fx.In
+
+	Lifecycle fx.Lifecycle
+	Config    dependency.ConfigGetter
+	Watcher   *Watcher
+	Logger    *zap.Logger `optional:"true"`
+}
+
+// Watch will reload the configuration when the configuration files change if
+// config-watch is set, and when the process receives SIGHUP if
+// config-reload-signal is set
+func Watch(params WatchParams) {
+	logger := params.Logger
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	reload := func(reason string) {
+		logger.Info("Reloading configuration", zap.String("reason", reason))
+		if err := params.Watcher.Reload(); err != nil {
+			logger.Error("Could not reload configuration", zap.Error(err))
+		}
+	}
+	if params.Config.GetBool("config-watch") && len(params.Watcher.Files) > 0 {
+		params.Lifecycle.Append(watchFiles(params.Watcher.Files, reload, logger))
+	}
+	if params.Config.GetBool("config-reload-signal") {
+		params.Lifecycle.Append(watchSignal(reload))
+	}
+}
+
+func watchFiles(files []string, reload func(reason string), logger *zap.Logger) fx.Hook {
+	var fileWatcher *fsnotify.Watcher
+	done := make(chan struct{})
+	return fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			var err error
+			fileWatcher, err = fsnotify.NewWatcher()
+			if err != nil {
+				return fmt.Errorf("could not watch config files, got error (%w)", err)
+			}
+			directories := map[string]struct{}{}
+			for _, file := range files {
+				directories[filepath.Dir(filepath.Clean(file))] = struct{}{}
+			}
+			for directory := range directories {
+				if err := fileWatcher.Add(directory); err != nil {
+					fileWatcher.Close()
+					return fmt.Errorf("could not watch config directory (%s), got error (%w)", directory, err)
+				}
+			}
+			go func() {
+				for {
+					select {
+					case event, ok := <-fileWatcher.Events:
+						if !ok {
+							return
+						}
+						if watched(files, event.Name) && event.Op&(fsnotify.Write|fsnotify.Create) != 0 {
+							reload(event.Name)
+						}
+					case err, ok := <-fileWatcher.Errors:
+						if !ok {
+							return
+						}
+						logger.Error("Could not watch the config files", zap.Error(err))
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			close(done)
+			return fileWatcher.Close()
+		},
+	}
+}
+
+// watched reports whether the given file is one of the files, or an
+// environment overlay of one of the files
+func watched(files []string, name string) bool {
+	name = filepath.Clean(name)
+	for _, file := range files {
+		file = filepath.Clean(file)
+		extension := filepath.Ext(file)
+		overlayPrefix := strings.TrimSuffix(file, extension) + "."
+		if name == file || (strings.HasPrefix(name, overlayPrefix) && strings.HasSuffix(name, extension)) {
+			return true
+		}
+	}
+	return false
+}
+
+func watchSignal(reload func(reason string)) fx.Hook {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	return fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			signal.Notify(signals, syscall.SIGHUP)
+			go func() {
+				for {
+					select {
+					case <-signals:
+						reload("SIGHUP")
+					case <-done:
+						return
+					}
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			signal.Stop(signals)
+			close(done)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,242 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx/fxtest"
+)
+
+func TestWatcher_Reload(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	file := filepath.Join(directory, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("changed: before\nunchanged: same\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	if err := cmd.Flags().Set("config", file); err != nil {
+		t.Fatal(err)
+	}
+	watcher := newWatcher(t, cmd)
+
+	changedValues := make([]string, 0, 1)
+	watcher.Subscribe(func(settings dependency.ConfigGetter) {
+		changedValues = append(changedValues, settings.GetString("changed"))
+	}, "changed")
+	unchangedCalls := 0
+	watcher.Subscribe(func(settings dependency.ConfigGetter) {
+		unchangedCalls++
+	}, "unchanged")
+
+	if err := ioutil.WriteFile(file, []byte("changed: after\nunchanged: same\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if len(changedValues) != 1 || changedValues[0] != "after" {
+		t.Fatalf("expected the subscriber to be called with (after), got (%+v)", changedValues)
+	}
+	if unchangedCalls != 0 {
+		t.Fatalf("expected the subscriber of an unchanged key not to be called, called (%d) time(s)", unchangedCalls)
+	}
+}
+
+func TestWatcher_ReloadFails(t *testing.T) {
+	cmd := &cobra.Command{}
+	watcher := newWatcher(t, cmd)
+	watcher.Files = []string{"testdata/missing.yaml"}
+	if err := watcher.Reload(); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func newWatcher(t *testing.T, cmd *cobra.Command) *config.Watcher {
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := config.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return config.NewWatcher(store, cmd)
+}
+
+func TestWatcher_ReloadKeepsConfigWhenLoadingFails(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	first, second := filepath.Join(directory, "first.yaml"), filepath.Join(directory, "second.yaml")
+	writeFile(t, first, "changed: before\n")
+	writeFile(t, second, "other: value\n")
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	if err := cmd.Flags().Set("config", first+","+second); err != nil {
+		t.Fatal(err)
+	}
+	watcher := newWatcher(t, cmd)
+
+	writeFile(t, first, "changed: after\n")
+	writeFile(t, second, "other: [not yaml\n")
+	if err := watcher.Reload(); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+	if value := watcher.Store.GetString("changed"); value != "before" {
+		t.Fatalf("expected the configuration to be kept as (before), got (%s)", value)
+	}
+}
+
+func TestWatcher_ReloadWhileReading(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	file := filepath.Join(directory, "config.yaml")
+	writeFile(t, file, "key: value\n")
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	if err := cmd.Flags().Set("config", file); err != nil {
+		t.Fatal(err)
+	}
+	watcher := newWatcher(t, cmd)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			if value := watcher.Store.GetString("key"); value != "value" {
+				t.Errorf("expected (value), got (%s)", value)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if err := watcher.Reload(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	<-done
+}
+
+func TestWatch(t *testing.T) {
+	tests := map[string]struct {
+		flag    string
+		trigger func(t *testing.T, file string)
+	}{
+		"file": {
+			flag: "config-watch",
+			trigger: func(t *testing.T, file string) {
+				writeFile(t, file, "key: after\n")
+			},
+		},
+		"signal": {
+			flag: "config-reload-signal",
+			trigger: func(t *testing.T, file string) {
+				writeFile(t, file, "key: after\n")
+				process, err := os.FindProcess(os.Getpid())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := process.Signal(syscall.SIGHUP); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			directory, err := ioutil.TempDir("", "watcher")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(directory)
+			file := filepath.Join(directory, "config.yaml")
+			writeFile(t, file, "key: before\n")
+			cmd := &cobra.Command{}
+			config.Service.ConfigFunc(cmd.Flags())
+			if err := cmd.Flags().Set("config", file); err != nil {
+				t.Fatal(err)
+			}
+			if err := cmd.Flags().Set(test.flag, "true"); err != nil {
+				t.Fatal(err)
+			}
+			watcher := newWatcher(t, cmd)
+			values := make(chan string, 10)
+			watcher.Subscribe(func(settings dependency.ConfigGetter) {
+				values <- settings.GetString("key")
+			}, "key")
+			lifecycle := fxtest.NewLifecycle(t)
+			config.Watch(config.WatchParams{Lifecycle: lifecycle, Config: watcher.Store, Watcher: watcher})
+			lifecycle.RequireStart()
+			defer lifecycle.RequireStop()
+
+			test.trigger(t, file)
+			select {
+			case value := <-values:
+				if value != "after" {
+					t.Fatalf("expected the subscriber to be called with (after), got (%s)", value)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("expected the configuration to be reloaded")
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, file, contents string) {
+	if err := ioutil.WriteFile(file, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher_ReloadResolvesSecrets(t *testing.T) {
+	directory, err := ioutil.TempDir("", "watcher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	secret := filepath.Join(directory, "secret")
+	writeFile(t, secret, "hunter2")
+	cmd := &cobra.Command{}
+	cmd.Flags().String("password", "file://"+secret, "")
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := config.NewStore(cfg, config.NewFileSecretResolver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher := config.NewWatcher(store, cmd)
+	passwords := make([]string, 0, 1)
+	watcher.Subscribe(func(settings dependency.ConfigGetter) {
+		passwords = append(passwords, settings.GetString("password"))
+	}, "password")
+
+	writeFile(t, secret, "hunter3")
+	if err := watcher.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(passwords) != 1 || passwords[0] != "hunter3" {
+		t.Fatalf("expected the subscriber to be called with the new secret, got (%+v)", passwords)
+	}
+	if value := store.Viper().GetString("password"); value != "file://"+secret {
+		t.Fatalf("expected the configuration to keep the reference, got (%s)", value)
+	}
+}
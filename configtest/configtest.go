@@ -0,0 +1,41 @@
+package configtest
+
+import (
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+)
+
+// RequireRegisteredKeys builds the application with the builder, populating the
+// given targets so that their constructors are run, and fails the test if any of
+// the keys read from the configuration have not been registered as flags. The
+// command of the builder is executed with config-strict set to warn, so that the
+// keys are recorded, and its Run is replaced.
+func RequireRegisteredKeys(tb fxtest.TB, builder dependency.Builder, targets ...interface{}) {
+	var recorder *config.KeyRecorder
+	args := []string{}
+	if builder.Cmd.PersistentFlags().Lookup("config-strict") != nil || builder.Cmd.Flags().Lookup("config-strict") != nil {
+		args = append(args, "--config-strict="+config.StrictWarn)
+	}
+	builder.Cmd.SetArgs(args)
+	builder.Cmd.Run = func(cmd *cobra.Command, args []string) {
+		builder.
+			WithModule(fx.Populate(append(targets, &recorder)...)).
+			WithModule(fx.NopLogger).
+			BuildTest(tb)
+	}
+	if err := builder.Cmd.Execute(); err != nil {
+		tb.Errorf("could not execute the command (%s), got error (%s)", builder.Cmd.Name(), err)
+		tb.FailNow()
+	}
+	if recorder == nil {
+		tb.Errorf("the builder for command (%s) does not provide a *config.KeyRecorder", builder.Cmd.Name())
+		tb.FailNow()
+	}
+	if err := recorder.Err(); err != nil {
+		tb.Errorf("%s", err)
+		tb.FailNow()
+	}
+}
@@ -1,6 +1,7 @@
 package framework
 
 import (
+	"github.com/BlackBX/service-framework/admin"
 	"github.com/BlackBX/service-framework/awscfg"
 	"github.com/BlackBX/service-framework/config"
 	"github.com/BlackBX/service-framework/dependency"
@@ -10,6 +11,7 @@ import (
 	"github.com/BlackBX/service-framework/newrelic"
 	"github.com/BlackBX/service-framework/postgres"
 	"github.com/BlackBX/service-framework/redis"
+	"github.com/BlackBX/service-framework/request"
 	"github.com/BlackBX/service-framework/response"
 	"github.com/BlackBX/service-framework/router"
 	"github.com/BlackBX/service-framework/server"
@@ -17,21 +19,76 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// DefaultServices are the services that will be added to an application
+// built by this package when they are required by another service
+var DefaultServices = []dependency.Service{
+	config.Service,
+	logging.Service,
+	health.Service,
+	response.Service,
+	request.Service,
+	router.Service,
+	httpclient.Service,
+	awscfg.Service,
+}
+
+// Profiles controls which of the optional infrastructure services are wired
+// into a web application, services that are disabled are not registered with
+// the builder, so they do not register their flags or provide their types.
+// When Admin is enabled the health checks are served by the admin server rather
+// than the public router.
+type Profiles struct {
+	Postgres bool
+	Redis    bool
+	Admin    bool
+}
+
+// DefaultWebProfiles are the Profiles used by NewWebApplicationBuilder, they wire
+// Postgres and Redis as NewWebApplicationBuilder always has, a stateless service
+// uses NewWebApplicationBuilderWithProfiles without them
+var DefaultWebProfiles = Profiles{
+	Postgres: true,
+	Redis:    true,
+}
+
 // NewWebApplicationBuilder will give you a builder that can
 // create a new web application
 func NewWebApplicationBuilder(command *cobra.Command) dependency.Builder {
-	return dependency.
+	return NewWebApplicationBuilderWithProfiles(command, DefaultWebProfiles)
+}
+
+// NewWebApplicationBuilderWithProfiles will give you a builder that can create
+// a new web application, with only the infrastructure enabled by the profiles
+func NewWebApplicationBuilderWithProfiles(command *cobra.Command, profiles Profiles) dependency.Builder {
+	healthService := health.Service
+	if profiles.Admin {
+		healthService = health.AdminService
+	}
+	// services are registered before the services that require them, so that
+	// they are not added from the defaults first
+	builder := dependency.
 		NewBuilder(command).
-		WithService(postgres.Service).
-		WithService(newrelic.Service).
+		WithDefaults(DefaultServices...).
 		WithService(config.Service).
 		WithService(logging.Service).
-		WithService(health.Service).
-		WithService(router.Service).
+		WithService(healthService).
 		WithService(response.Service).
-		WithService(redis.Service).
+		WithService(request.Service).
+		WithService(router.Service)
+	if profiles.Postgres {
+		builder = builder.WithService(postgres.Service)
+	}
+	builder = builder.WithService(newrelic.Service)
+	if profiles.Redis {
+		builder = builder.WithService(redis.Service)
+	}
+	builder = builder.
 		WithService(httpclient.Service).
 		WithService(server.Service)
+	if profiles.Admin {
+		builder = builder.WithService(admin.Service)
+	}
+	return builder
 }
 
 // NewQueueApplicationBuilder will create a dependency.Builder that will
@@ -39,9 +96,10 @@ func NewWebApplicationBuilder(command *cobra.Command) dependency.Builder {
 func NewQueueApplicationBuilder(command *cobra.Command) dependency.Builder {
 	return dependency.
 		NewBuilder(command).
-		WithService(newrelic.Service).
+		WithDefaults(DefaultServices...).
 		WithService(config.Service).
 		WithService(logging.Service).
+		WithService(newrelic.Service).
 		WithService(httpclient.Service).
 		WithService(awscfg.Service).
 		WithService(sqs.Service)
@@ -0,0 +1,98 @@
+package framework_test
+
+import (
+	"database/sql"
+	"testing"
+
+	framework "github.com/BlackBX/service-framework"
+	"github.com/BlackBX/service-framework/admin"
+	"github.com/BlackBX/service-framework/configtest"
+	"github.com/BlackBX/service-framework/health"
+	"github.com/BlackBX/service-framework/postgres"
+	"github.com/BlackBX/service-framework/redis"
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func TestNewWebApplicationBuilderWithProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles framework.Profiles
+		postgres bool
+		redis    bool
+		admin    bool
+	}{
+		{
+			name:     "default",
+			profiles: framework.DefaultWebProfiles,
+			postgres: true,
+			redis:    true,
+		},
+		{
+			name:     "postgres only",
+			profiles: framework.Profiles{Postgres: true},
+			postgres: true,
+		},
+		{
+			name: "stateless",
+		},
+		{
+			name:     "admin",
+			profiles: framework.Profiles{Admin: true},
+			admin:    true,
+		},
+		{
+			name:     "all",
+			profiles: framework.Profiles{Postgres: true, Redis: true, Admin: true},
+			postgres: true,
+			redis:    true,
+			admin:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			builder := framework.NewWebApplicationBuilderWithProfiles(cmd, test.profiles)
+			if err := builder.Validate(); err != nil {
+				t.Fatalf("expected no error, got (%s)", err)
+			}
+			if builder.HasService(postgres.Service.Name) != test.postgres {
+				t.Errorf("expected postgres to be registered (%t)", test.postgres)
+			}
+			if builder.HasService(redis.Service.Name) != test.redis {
+				t.Errorf("expected redis to be registered (%t)", test.redis)
+			}
+			if builder.HasService(admin.Service.Name) != test.admin {
+				t.Errorf("expected admin to be registered (%t)", test.admin)
+			}
+			expectedGroup := "server"
+			if test.admin {
+				expectedGroup = "admin"
+			}
+			healthService, ok := builder.Service(health.Service.Name)
+			if !ok {
+				t.Fatal("expected health to be registered")
+			}
+			if group := healthService.Constructor.(fx.Annotated).Group; group != expectedGroup {
+				t.Errorf("expected the health checks to be in the (%s) group, got (%s)", expectedGroup, group)
+			}
+			if (cmd.PersistentFlags().Lookup("postgres-host") != nil) != test.postgres {
+				t.Errorf("expected postgres flags to be registered (%t)", test.postgres)
+			}
+			if (cmd.PersistentFlags().Lookup("redis-host") != nil) != test.redis {
+				t.Errorf("expected redis flags to be registered (%t)", test.redis)
+			}
+		})
+	}
+}
+
+func TestNewWebApplicationBuilderRegisteredKeys(t *testing.T) {
+	cmd := &cobra.Command{}
+	var (
+		db     *sql.DB
+		client redis.Cmdable
+		app    *newrelic.Application
+	)
+	configtest.RequireRegisteredKeys(t, framework.NewWebApplicationBuilder(cmd), &db, &client, &app)
+}
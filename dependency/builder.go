@@ -1,7 +1,11 @@
 package dependency
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"go.uber.org/fx"
 	"go.uber.org/fx/fxtest"
 )
@@ -11,18 +15,41 @@ import (
 type ConfigRegisterer func(set FlagSet)
 
 // Service is a dependency that is required by multiple modules of the
-// application, such as logging.
+// application, such as logging. The Name of the service is used by the
+// Builder to de-duplicate, replace and remove services, services without
+// a Name are always added. Requires holds the names of the services that
+// the service depends upon.
 type Service struct {
 	Name         string
+	Requires     []string
 	ConfigFunc   ConfigRegisterer
 	Dependencies fx.Option
 	Constructor  interface{}
 	InvokeFunc   interface{}
 }
 
+// options produces the fx.Options required to add the service to an app
+func (s Service) options() []fx.Option {
+	options := make([]fx.Option, 0, 3)
+	if s.Constructor != nil {
+		options = append(options, fx.Provide(s.Constructor))
+	}
+	if s.Dependencies != nil {
+		options = append(options, s.Dependencies)
+	}
+	if s.InvokeFunc != nil {
+		options = append(options, fx.Invoke(s.InvokeFunc))
+	}
+	return options
+}
+
 // NewBuilder creates a new instance of the Builder type, by default it will provide
-// the cobra.Command as a dependency.
+// the cobra.Command as a dependency, and add the describe sub-command to it when
+// the command does not already have one.
 func NewBuilder(cmd *cobra.Command) Builder {
+	if !hasSubCommand(cmd, "describe") {
+		cmd.AddCommand(NewDescribeCommand(cmd))
+	}
 	return Builder{
 		Cmd: cmd,
 		Provide: []interface{}{
@@ -30,18 +57,25 @@ func NewBuilder(cmd *cobra.Command) Builder {
 				return cmd
 			},
 		},
-		Invoke:  []fx.Option{},
-		Options: []fx.Option{},
+		Services: []Service{},
+		Defaults: []Service{},
+		Invoke:   []fx.Option{},
+		Options:  []fx.Option{},
 	}
 }
 
 // Builder is a type that will build an *fx.App from dependencies, it stores the command
 // that started the app, along with all of the dependencies required to start the app.
 type Builder struct {
-	Cmd     *cobra.Command
-	Provide []interface{}
-	Invoke  []fx.Option
-	Options []fx.Option
+	Cmd      *cobra.Command
+	Provide  []interface{}
+	Services []Service
+	Defaults []Service
+	Invoke   []fx.Option
+	Options  []fx.Option
+
+	flagErrors    []string
+	serviceErrors []string
 }
 
 // WithConstructor is analogous to the fx.Provide option, it allows you to provide multiple
@@ -52,28 +86,216 @@ func (b Builder) WithConstructor(constructors ...interface{}) Builder {
 }
 
 // WithService allows you to register a Service dependency, this should be done within
-// an `invoke()` function, so that the flags from the
+// an `invoke()` function, so that the flags from the service are registered before
+// the command is executed. If a service with the same name has already been
+// registered, including a default added as the requirement of another service,
+// Build fails, ReplaceService replaces a registered service. Any services that are
+// required by the service which have not been registered are added from the
+// Builder's defaults.
 func (b Builder) WithService(service Service) Builder {
-	b = b.WithConstructor(service.Constructor)
-	if service.Dependencies != nil {
-		b.Options = append(b.Options, service.Dependencies)
+	if service.Name != "" && b.HasService(service.Name) {
+		b.serviceErrors = append(b.serviceErrors[:len(b.serviceErrors):len(b.serviceErrors)], fmt.Sprintf(
+			"service (%s) is already registered, use ReplaceService to replace it", service.Name,
+		))
+		return b
+	}
+	b.Services = append(b.Services[:len(b.Services):len(b.Services)], service)
+	if service.ConfigFunc != nil {
+		b = b.registerConfig(service.ConfigFunc, service.Name)
+	}
+	return b.withRequirements(service)
+}
+
+// WithDefaults registers services that will be added to the Builder when they
+// are required by another service, but have not been registered. Defaults must
+// be registered before the services that require them.
+func (b Builder) WithDefaults(services ...Service) Builder {
+	b.Defaults = append(b.Defaults[:len(b.Defaults):len(b.Defaults)], services...)
+	return b
+}
+
+func (b Builder) withRequirements(service Service) Builder {
+	for _, name := range service.Requires {
+		if b.HasService(name) {
+			continue
+		}
+		for _, defaultService := range b.Defaults {
+			if defaultService.Name == name {
+				b = b.WithService(defaultService)
+				break
+			}
+		}
+	}
+	return b
+}
+
+// Validate checks that every service required by a registered service has
+// also been registered, that services have not been registered twice, and that
+// services have not registered the same flags
+func (b Builder) Validate() error {
+	if len(b.serviceErrors) > 0 {
+		return fmt.Errorf("command (%s) has duplicate services: %s", b.Cmd.Name(), strings.Join(b.serviceErrors, ", "))
+	}
+	if len(b.flagErrors) > 0 {
+		return fmt.Errorf("command (%s) has conflicting flags: %s", b.Cmd.Name(), strings.Join(b.flagErrors, ", "))
+	}
+	missing := make([]string, 0)
+	for _, service := range b.Services {
+		for _, name := range service.Requires {
+			if b.HasService(name) {
+				continue
+			}
+			missing = append(missing, fmt.Sprintf("service (%s) requires (%s)", serviceName(service), name))
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf(
+		"command (%s) is missing required services: %s",
+		b.Cmd.Name(),
+		strings.Join(missing, ", "),
+	)
+}
+
+func serviceName(service Service) string {
+	if service.Name == "" {
+		return "unnamed"
+	}
+	return service.Name
+}
+
+// ReplaceService will replace the service registered with the given name with
+// the given service, if no service has been registered with the name then the
+// service is added. Flags registered by the replacement service take precedence
+// over those registered by the service it replaces.
+func (b Builder) ReplaceService(name string, service Service) Builder {
+	index := b.serviceIndex(name)
+	if index == -1 {
+		return b.WithService(service)
 	}
+	services := make([]Service, len(b.Services))
+	copy(services, b.Services)
+	services[index] = service
+	b.Services = services
 	if service.ConfigFunc != nil {
-		b = b.RegisterConfig(service.ConfigFunc)
+		b = b.registerConfig(service.ConfigFunc, service.Name, name)
 	}
-	if service.InvokeFunc == nil {
+	return b.withRequirements(service)
+}
+
+// WithoutService will remove the service registered with the given name, flags
+// that have already been registered by the service remain registered, but are
+// hidden and can be registered by another service. Services that require the
+// removed service will cause Build to fail.
+func (b Builder) WithoutService(name string) Builder {
+	index := b.serviceIndex(name)
+	if index == -1 {
 		return b
 	}
-	return b.WithInvoke(service.InvokeFunc)
+	services := make([]Service, 0, len(b.Services)-1)
+	services = append(services, b.Services[:index]...)
+	b.Services = append(services, b.Services[index+1:]...)
+	b.Cmd.PersistentFlags().VisitAll(func(flag *pflag.Flag) {
+		if owner := flag.Annotations[serviceAnnotation]; len(owner) == 1 && owner[0] == name {
+			flag.Annotations[serviceAnnotation] = []string{}
+			flag.Hidden = true
+		}
+	})
+	return b
+}
+
+// HasService reports whether a service with the given name has been registered
+func (b Builder) HasService(name string) bool {
+	return b.serviceIndex(name) != -1
+}
+
+// Service returns the service registered with the given name
+func (b Builder) Service(name string) (Service, bool) {
+	index := b.serviceIndex(name)
+	if index == -1 {
+		return Service{}, false
+	}
+	return b.Services[index], true
+}
+
+// ServiceNames returns the names of all of the named services, in the order that
+// they were registered
+func (b Builder) ServiceNames() []string {
+	names := make([]string, 0, len(b.Services))
+	for _, service := range b.Services {
+		if service.Name != "" {
+			names = append(names, service.Name)
+		}
+	}
+	return names
+}
+
+func (b Builder) serviceIndex(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, service := range b.Services {
+		if service.Name == name {
+			return i
+		}
+	}
+	return -1
 }
 
 // RegisterConfig will register the given config with the *cobra.Command held
-// by the Builder
+// by the Builder, flags that have already been registered are redefined
 func (b Builder) RegisterConfig(registerer ConfigRegisterer) Builder {
-	registerer(b.Cmd.PersistentFlags())
+	return b.registerConfig(registerer, "")
+}
+
+// serviceAnnotation is the annotation of a flag that holds the name of the
+// service that registered it
+const serviceAnnotation = "service"
+
+// registerConfig registers the config of a service, the flags that have already
+// been registered by one of the owners, or by a removed service, are redefined,
+// keeping their shorthand. Flags that have been registered by anything else cause
+// Build to fail, unless the config has no owner.
+func (b Builder) registerConfig(registerer ConfigRegisterer, owners ...string) Builder {
+	flags := pflag.NewFlagSet(b.Cmd.Name(), pflag.ContinueOnError)
+	registerer(flags)
+	persistentFlags := b.Cmd.PersistentFlags()
+	flags.VisitAll(func(flag *pflag.Flag) {
+		if owners[0] != "" {
+			flag.Annotations = map[string][]string{serviceAnnotation: {owners[0]}}
+		}
+		existing := persistentFlags.Lookup(flag.Name)
+		if existing == nil {
+			persistentFlags.AddFlag(flag)
+			return
+		}
+		owner, owned := existing.Annotations[serviceAnnotation]
+		if owners[0] == "" || (owned && len(owner) == 0) {
+			redefine(existing, flag)
+			return
+		}
+		for _, name := range owners {
+			if name != "" && len(owner) == 1 && owner[0] == name {
+				redefine(existing, flag)
+				return
+			}
+		}
+		b.flagErrors = append(b.flagErrors[:len(b.flagErrors):len(b.flagErrors)], fmt.Sprintf(
+			"flag (%s) of service (%s) is already registered by (%s)",
+			flag.Name, serviceName(Service{Name: owners[0]}), serviceName(Service{Name: strings.Join(owner, ", ")}),
+		))
+	})
 	return b
 }
 
+// redefine replaces the existing flag with the flag, keeping its shorthand
+func redefine(existing, flag *pflag.Flag) {
+	shorthand := existing.Shorthand
+	*existing = *flag
+	existing.Shorthand = shorthand
+}
+
 // WithModule adds an fx.Option into the list of dependencies to be built, used for adding
 // application modules
 func (b Builder) WithModule(module fx.Option) Builder {
@@ -87,20 +309,41 @@ func (b Builder) WithInvoke(funcs ...interface{}) Builder {
 	return b
 }
 
-// Build will produce a new instance of the *fx.App from the variables of the builder
+// Build will produce a new instance of the *fx.App from the variables of the builder,
+// if the services registered are not valid the app will fail to start. When the
+// describe sub-command is being run, the app will describe itself and stop.
 func (b Builder) Build() *fx.App {
-	return fx.New(
-		fx.Provide(b.Provide...),
-		fx.Options(b.Invoke...),
-		fx.Options(b.Options...),
-	)
+	return fx.New(b.options()...)
 }
 
 // BuildTest will create a new instance of *fxtest.App from the contained dependencies
 func (b Builder) BuildTest(tb fxtest.TB) *fxtest.App {
-	return fxtest.New(
-		tb,
+	return fxtest.New(tb, b.options()...)
+}
+
+func (b Builder) options() []fx.Option {
+	if err := b.Validate(); err != nil {
+		return []fx.Option{fx.Error(err)}
+	}
+	options := []fx.Option{
 		fx.Provide(b.Provide...),
+	}
+	if b.describing() {
+		for _, service := range b.Services {
+			service.InvokeFunc = nil
+			options = append(options, service.options()...)
+		}
+		return append(
+			options,
+			fx.Options(b.Options...),
+			fx.Invoke(b.describe),
+		)
+	}
+	for _, service := range b.Services {
+		options = append(options, service.options()...)
+	}
+	return append(
+		options,
 		fx.Options(b.Invoke...),
 		fx.Options(b.Options...),
 	)
@@ -2,6 +2,7 @@ package dependency_test
 
 import (
 	"net/http"
+	"reflect"
 	"testing"
 
 	"github.com/BlackBX/service-framework/dependency"
@@ -40,15 +41,13 @@ func TestBuilder_WithModule(t *testing.T) {
 
 func TestBuilder_WithService(t *testing.T) {
 	tests := []struct {
-		name            string
-		expectedOptions int
-		expectedInvokes int
-		service         dependency.Service
+		name             string
+		expectedServices int
+		service          dependency.Service
 	}{
 		{
-			name:            "no invoke",
-			expectedInvokes: 0,
-			expectedOptions: 1,
+			name:             "no invoke",
+			expectedServices: 1,
 			service: dependency.Service{
 				Dependencies: fx.Options(),
 				Constructor: func() string {
@@ -58,9 +57,8 @@ func TestBuilder_WithService(t *testing.T) {
 			},
 		},
 		{
-			name:            "invoke",
-			expectedInvokes: 1,
-			expectedOptions: 1,
+			name:             "invoke",
+			expectedServices: 1,
 			service: dependency.Service{
 				Dependencies: fx.Options(),
 				InvokeFunc:   func(command *cobra.Command) {},
@@ -74,11 +72,8 @@ func TestBuilder_WithService(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			builder := dependency.NewBuilder(&cobra.Command{})
 			builder = builder.WithService(test.service)
-			if len(builder.Options) != test.expectedOptions {
-				t.Errorf("expected (%d), options, got (%d) options", test.expectedOptions, len(builder.Options))
-			}
-			if len(builder.Invoke) != test.expectedInvokes {
-				t.Errorf("expected (%d) invokes, got (%d) invokes", test.expectedInvokes, len(builder.Invoke))
+			if len(builder.Services) != test.expectedServices {
+				t.Errorf("expected (%d) services, got (%d) services", test.expectedServices, len(builder.Services))
 			}
 			_ = builder.Build()
 			_ = builder.BuildTest(t)
@@ -100,3 +95,192 @@ func TestBuilder_WithServiceCallsConfigFunc(t *testing.T) {
 		t.Fatalf("expected to be called 1 time, called (%d) times", timesCalled)
 	}
 }
+
+func TestBuilder_WithServiceRejectsDuplicates(t *testing.T) {
+	timesCalled := 0
+	service := dependency.Service{
+		Name: "foo",
+		ConfigFunc: func(set dependency.FlagSet) {
+			timesCalled++
+			set.String("foo", "bar", "A flag that can only be registered once")
+		},
+		Constructor: func() string {
+			return "foo"
+		},
+	}
+	builder := dependency.NewBuilder(&cobra.Command{}).
+		WithService(service).
+		WithService(service)
+	if len(builder.Services) != 1 {
+		t.Fatalf("expected there to be 1 service, got (%d)", len(builder.Services))
+	}
+	if timesCalled != 1 {
+		t.Fatalf("expected config func to be called 1 time, called (%d) times", timesCalled)
+	}
+	expected := "command () has duplicate services: service (foo) is already registered, use ReplaceService to replace it"
+	if err := builder.Validate(); err == nil || err.Error() != expected {
+		t.Fatalf("expected the error (%s), got (%v)", expected, err)
+	}
+}
+
+func TestBuilder_WithServiceRejectsDuplicateDefaults(t *testing.T) {
+	builder := dependency.NewBuilder(&cobra.Command{}).
+		WithDefaults(dependency.Service{Name: "bar"}).
+		WithService(dependency.Service{Name: "foo", Requires: []string{"bar"}}).
+		WithService(dependency.Service{Name: "bar"})
+	if err := builder.Validate(); err == nil {
+		t.Fatal("expected the service added after its default to fail validation")
+	}
+}
+
+func TestBuilder_RegisterConfigRedefinesFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		RegisterConfig(func(set dependency.FlagSet) {
+			set.StringP("foo", "f", "bar", "A flag")
+		}).
+		RegisterConfig(func(set dependency.FlagSet) {
+			set.String("foo", "baz", "The redefined flag")
+		})
+	if err := builder.Validate(); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	flag := cmd.PersistentFlags().Lookup("foo")
+	if flag.DefValue != "baz" || flag.Shorthand != "f" {
+		t.Fatalf("expected the flag to be redefined with its shorthand, got (%+v)", flag)
+	}
+}
+
+func TestBuilder_ReplaceService(t *testing.T) {
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(dependency.Service{
+			Name: "foo",
+			ConfigFunc: func(set dependency.FlagSet) {
+				set.String("foo", "bar", "The original flag")
+			},
+			Constructor: func() string {
+				return "original"
+			},
+		}).
+		ReplaceService("foo", dependency.Service{
+			Name: "foo",
+			ConfigFunc: func(set dependency.FlagSet) {
+				set.String("foo", "baz", "The replaced flag")
+			},
+			Constructor: func() string {
+				return "replaced"
+			},
+		})
+	if len(builder.Services) != 1 {
+		t.Fatalf("expected there to be 1 service, got (%d)", len(builder.Services))
+	}
+	flag := cmd.PersistentFlags().Lookup("foo")
+	if flag == nil || flag.DefValue != "baz" {
+		t.Fatalf("expected the flag to be redefined by the replacement, got (%+v)", flag)
+	}
+	got := ""
+	app := builder.
+		WithInvoke(func(value string) {
+			got = value
+		}).
+		BuildTest(t)
+	app.RequireStart().RequireStop()
+	if got != "replaced" {
+		t.Fatalf("expected (replaced), got (%s)", got)
+	}
+}
+
+func TestBuilder_ConflictingFlags(t *testing.T) {
+	register := func(set dependency.FlagSet) {
+		set.StringP("foo", "f", "bar", "A flag")
+	}
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(dependency.Service{Name: "foo", ConfigFunc: register}).
+		WithService(dependency.Service{Name: "bar", ConfigFunc: register})
+	expected := "command () has conflicting flags: flag (foo) of service (bar) is already registered by (foo)"
+	if err := builder.Validate(); err == nil || err.Error() != expected {
+		t.Fatalf("expected the error (%s), got (%v)", expected, err)
+	}
+	if flag := cmd.PersistentFlags().ShorthandLookup("f"); flag == nil || flag.Name != "foo" {
+		t.Fatalf("expected the shorthand to be kept, got (%+v)", flag)
+	}
+}
+
+func TestBuilder_ReplaceServiceAddsMissingService(t *testing.T) {
+	builder := dependency.NewBuilder(&cobra.Command{}).
+		ReplaceService("foo", dependency.Service{Name: "foo"})
+	if !builder.HasService("foo") {
+		t.Fatal("expected service (foo) to be registered")
+	}
+}
+
+func TestBuilder_WithoutService(t *testing.T) {
+	original := dependency.NewBuilder(&cobra.Command{}).
+		WithService(dependency.Service{Name: "foo"}).
+		WithService(dependency.Service{Name: "bar"}).
+		WithService(dependency.Service{Name: "baz"})
+	builder := original.WithoutService("bar")
+	expectedNames := []string{"foo", "baz"}
+	if !reflect.DeepEqual(expectedNames, builder.ServiceNames()) {
+		t.Fatalf("expected (%+v), got (%+v)", expectedNames, builder.ServiceNames())
+	}
+	if !original.HasService("bar") {
+		t.Fatal("expected the original builder to be unchanged")
+	}
+	if _, ok := builder.Service("bar"); ok {
+		t.Fatal("expected service (bar) to be removed")
+	}
+}
+
+func TestBuilder_WithoutServiceReleasesFlags(t *testing.T) {
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(dependency.Service{Name: "foo", ConfigFunc: func(set dependency.FlagSet) {
+			set.String("foo", "bar", "The flag of foo")
+		}}).
+		WithoutService("foo").
+		WithService(dependency.Service{Name: "baz", ConfigFunc: func(set dependency.FlagSet) {
+			set.String("foo", "baz", "The flag of baz")
+		}})
+	if err := builder.Validate(); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	flag := cmd.PersistentFlags().Lookup("foo")
+	if flag.DefValue != "baz" || flag.Hidden || flag.Annotations["service"][0] != "baz" {
+		t.Fatalf("expected the flag to be registered by (baz), got (%+v)", flag)
+	}
+}
+
+func TestBuilder_WithServiceAddsDefaults(t *testing.T) {
+	builder := dependency.NewBuilder(&cobra.Command{}).
+		WithDefaults(
+			dependency.Service{Name: "bar", Requires: []string{"baz"}},
+			dependency.Service{Name: "baz"},
+		).
+		WithService(dependency.Service{Name: "foo", Requires: []string{"bar"}})
+	expectedNames := []string{"foo", "bar", "baz"}
+	if !reflect.DeepEqual(expectedNames, builder.ServiceNames()) {
+		t.Fatalf("expected (%+v), got (%+v)", expectedNames, builder.ServiceNames())
+	}
+	if err := builder.Validate(); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+}
+
+func TestBuilder_ValidateMissingService(t *testing.T) {
+	builder := dependency.NewBuilder(&cobra.Command{Use: "serve"}).
+		WithService(dependency.Service{Name: "foo", Requires: []string{"bar"}})
+	err := builder.Validate()
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	expectedError := "command (serve) is missing required services: service (foo) requires (bar)"
+	if err.Error() != expectedError {
+		t.Fatalf("expected error (%s), got (%s)", expectedError, err)
+	}
+	if err := builder.Build().Err(); err == nil {
+		t.Fatal("expected the app to fail to build, got nil")
+	}
+}
@@ -0,0 +1,199 @@
+package dependency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/fx"
+)
+
+const describeAnnotation = "dependency.describe"
+
+// SecretFlags are the parts of flag names that mark a flag as holding a secret,
+// the values of secret flags are masked by the describe command
+var SecretFlags = []string{"password", "secret", "token", "license-key", "access-key"}
+
+// DescribeSection is a section of the output of the describe command, sections
+// are provided to the application in the "describe" group
+type DescribeSection struct {
+	Title string
+	Write func(w io.Writer) error
+}
+
+// FlagDescriber is an interface that describes the effective value of a flag,
+// along with where that value came from
+type FlagDescriber interface {
+	DescribeFlag(flag *pflag.Flag) (value, source string)
+}
+
+// NewDescribeCommand creates the describe sub-command of the given command, when
+// it is executed the given command is run, but the application that it builds
+// will print its services, configuration and dependency graph rather than start.
+// The annotations of the command are restored once it has been described, so
+// that it can be run afterwards.
+func NewDescribeCommand(cmd *cobra.Command) *cobra.Command {
+	return &cobra.Command{
+		Use:   "describe",
+		Short: fmt.Sprintf("Describe the %s command", cmd.Name()),
+		Long:  "Print the services, configuration and dependency graph of the application",
+		RunE: func(describe *cobra.Command, args []string) error {
+			annotations := cmd.Annotations
+			defer func() {
+				cmd.Annotations = annotations
+			}()
+			cmd.Annotations = map[string]string{describeAnnotation: "true"}
+			for key, value := range annotations {
+				cmd.Annotations[key] = value
+			}
+			cmd.Flags().AddFlagSet(cmd.PersistentFlags())
+			cmd.Flags().AddFlagSet(cmd.InheritedFlags())
+			cmd.SetOut(describe.OutOrStdout())
+			switch {
+			case cmd.RunE != nil:
+				return cmd.RunE(cmd, args)
+			case cmd.Run != nil:
+				cmd.Run(cmd, args)
+				return nil
+			}
+			return fmt.Errorf("the command (%s) cannot be described as it does not run", cmd.Name())
+		},
+	}
+}
+
+func hasSubCommand(cmd *cobra.Command, name string) bool {
+	for _, subCommand := range cmd.Commands() {
+		if subCommand.Name() == name {
+			return true
+		}
+	}
+	return false
+}
+
+type describeParams struct {
+	fx.In
+
+	Lifecycle     fx.Lifecycle
+	Shutdowner    fx.Shutdowner
+	Graph         fx.DotGraph
+	FlagDescriber FlagDescriber     `optional:"true"`
+	Sections      []DescribeSection `group:"describe"`
+}
+
+func (b Builder) describing() bool {
+	_, ok := b.Cmd.Annotations[describeAnnotation]
+	return ok
+}
+
+// describe prints the description of the application once it has started, and
+// then shuts the application down
+func (b Builder) describe(params describeParams) {
+	params.Lifecycle.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			if err := b.writeDescription(b.Cmd.OutOrStdout(), params); err != nil {
+				return fmt.Errorf("could not describe the command (%s), got error (%w)", b.Cmd.Name(), err)
+			}
+			return params.Shutdowner.Shutdown()
+		},
+	})
+}
+
+// writeDescription writes the description of the registered services, and the
+// sections provided to the application to the given writer
+func (b Builder) writeDescription(w io.Writer, params describeParams) error {
+	sections := []DescribeSection{
+		{
+			Title: "Services",
+			Write: func(w io.Writer) error {
+				return b.describeServices(w, params.FlagDescriber)
+			},
+		},
+	}
+	extraSections := append([]DescribeSection{}, params.Sections...)
+	sort.SliceStable(extraSections, func(i, j int) bool {
+		return extraSections[i].Title < extraSections[j].Title
+	})
+	sections = append(sections, extraSections...)
+	sections = append(sections, DescribeSection{
+		Title: "Dependency Graph",
+		Write: func(w io.Writer) error {
+			_, err := fmt.Fprintln(w, params.Graph)
+			return err
+		},
+	})
+	for _, section := range sections {
+		if _, err := fmt.Fprintf(w, "%s\n%s\n", section.Title, strings.Repeat("=", len(section.Title))); err != nil {
+			return err
+		}
+		if err := section.Write(w); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b Builder) describeServices(w io.Writer, describer FlagDescriber) error {
+	commandFlags := b.Cmd.Flags()
+	for _, service := range b.Services {
+		if _, err := fmt.Fprintln(w, serviceName(service)); err != nil {
+			return err
+		}
+		if len(service.Requires) > 0 {
+			if _, err := fmt.Fprintf(w, "  requires: %s\n", strings.Join(service.Requires, ", ")); err != nil {
+				return err
+			}
+		}
+		if service.ConfigFunc == nil {
+			continue
+		}
+		flags := pflag.NewFlagSet(service.Name, pflag.ContinueOnError)
+		service.ConfigFunc(flags)
+		var err error
+		flags.VisitAll(func(flag *pflag.Flag) {
+			if err != nil {
+				return
+			}
+			if registered := commandFlags.Lookup(flag.Name); registered != nil {
+				flag = registered
+			}
+			value, source := describeFlag(flag, describer)
+			_, err = fmt.Fprintf(w, "  --%s = %s (%s)\n", flag.Name, value, source)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func describeFlag(flag *pflag.Flag, describer FlagDescriber) (value, source string) {
+	if describer != nil {
+		value, source = describer.DescribeFlag(flag)
+	} else {
+		value, source = flag.Value.String(), "default"
+		if flag.Changed {
+			source = "flag"
+		}
+	}
+	if value != "" && IsSecretFlag(flag.Name) {
+		value = "********"
+	}
+	return fmt.Sprintf("%q", value), source
+}
+
+// IsSecretFlag reports whether the flag with the given name holds a secret
+func IsSecretFlag(name string) bool {
+	for _, secret := range SecretFlags {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package dependency_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/spf13/cobra"
+	"go.uber.org/fx"
+)
+
+func TestDescribeCommand(t *testing.T) {
+	root := &cobra.Command{Use: "root"}
+	cmd := &cobra.Command{Use: "serve"}
+	root.AddCommand(cmd)
+	builder := dependency.NewBuilder(cmd).
+		WithService(dependency.Service{
+			Name:     "foo",
+			Requires: []string{"bar"},
+			ConfigFunc: func(set dependency.FlagSet) {
+				set.String("foo-host", "localhost", "The host")
+				set.String("foo-password", "", "The password")
+			},
+			Constructor: func() string {
+				return "foo"
+			},
+			InvokeFunc: func(string) {
+				t.Error("expected the invoke function not to be called")
+			},
+		}).
+		WithService(dependency.Service{
+			Name: "bar",
+			Constructor: fx.Annotated{
+				Group: "describe",
+				Target: func() dependency.DescribeSection {
+					return dependency.DescribeSection{
+						Title: "Bar",
+						Write: func(w io.Writer) error {
+							_, err := fmt.Fprintln(w, "bar section")
+							return err
+						},
+					}
+				},
+			},
+		}).
+		WithModule(fx.NopLogger)
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		builder.Build().Run()
+	}
+	output := &bytes.Buffer{}
+	root.SetOut(output)
+	root.SetArgs([]string{"serve", "describe", "--foo-password", "hunter2"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	expectedParts := []string{
+		"Services\n========\nfoo\n  requires: bar\n",
+		`  --foo-host = "localhost" (default)`,
+		`  --foo-password = "********" (flag)`,
+		"Bar\n===\nbar section\n",
+		"Dependency Graph\n================\ndigraph",
+	}
+	got := output.String()
+	for _, part := range expectedParts {
+		if !strings.Contains(got, part) {
+			t.Errorf("expected output to contain (%s), got (%s)", part, got)
+		}
+	}
+	if strings.Contains(got, "hunter2") {
+		t.Errorf("expected the secret to be masked, got (%s)", got)
+	}
+}
+
+func TestNewBuilderAddsOneDescribeCommand(t *testing.T) {
+	cmd := &cobra.Command{Use: "serve"}
+	dependency.NewBuilder(cmd)
+	dependency.NewBuilder(cmd)
+	if len(cmd.Commands()) != 1 {
+		t.Fatalf("expected (1) describe sub-command, got (%d)", len(cmd.Commands()))
+	}
+}
+
+func TestDescribeCommandRestoresCommand(t *testing.T) {
+	cmd := &cobra.Command{Use: "serve", Annotations: map[string]string{"owner": "todos"}}
+	invoked := 0
+	builder := dependency.NewBuilder(cmd).
+		WithService(dependency.Service{
+			Name:        "foo",
+			Constructor: func() string { return "foo" },
+			InvokeFunc: func(string) {
+				invoked++
+			},
+		}).
+		WithModule(fx.NopLogger)
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return builder.Build().Err()
+	}
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetArgs([]string{"describe"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if invoked != 0 || len(cmd.Annotations) != 1 || cmd.Annotations["owner"] != "todos" {
+		t.Fatalf("expected the command to be described, got (%d) invocations and annotations (%v)", invoked, cmd.Annotations)
+	}
+	cmd.SetArgs([]string{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if invoked != 1 {
+		t.Fatalf("expected the command to run after being described, got (%d) invocations", invoked)
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/BlackBX/service-framework/request"
 	"github.com/BlackBX/service-framework/response"
-	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
 
@@ -18,10 +18,16 @@ type TodoModel struct {
 	Completed bool   `json:"completed"`
 }
 
+// TodoRequest is the request for a todo
+type TodoRequest struct {
+	ID int `path:"id" validate:"min=1"`
+}
+
 // NewHTTPHandler produces a new instance of the HTTPHandler type
-func NewHTTPHandler(provider response.ResponderProvider, logger *zap.Logger, client *http.Client) HTTPHandler {
+func NewHTTPHandler(provider response.ResponderProvider, decoder request.Decoder, logger *zap.Logger, client *http.Client) HTTPHandler {
 	return HTTPHandler{
 		ResponseProvider: provider,
+		Decoder:          decoder,
 		Logger:           logger,
 		Client:           client,
 	}
@@ -30,19 +36,19 @@ func NewHTTPHandler(provider response.ResponderProvider, logger *zap.Logger, cli
 // HTTPHandler is a type that will reach out to a third party service
 type HTTPHandler struct {
 	ResponseProvider response.ResponderProvider
+	Decoder          request.Decoder
 	Logger           *zap.Logger
 	Client           *http.Client
 }
 
 // Get is the function that is called when the route is hit
 func (h HTTPHandler) Get(w http.ResponseWriter, r *http.Request) {
-	responder := h.ResponseProvider.Responder(w, r)
-	id, ok := mux.Vars(r)["id"]
-	if !ok {
-		responder.RespondWithProblem(http.StatusBadRequest, "Please specify an ID")
+	todoRequest := TodoRequest{}
+	if !h.Decoder.DecodeOrRespond(w, r, &todoRequest) {
 		return
 	}
-	url := fmt.Sprintf("https://jsonplaceholder.typicode.com/todos/%s", id)
+	responder := h.ResponseProvider.Responder(w, r)
+	url := fmt.Sprintf("https://jsonplaceholder.typicode.com/todos/%d", todoRequest.ID)
 	req, err := http.NewRequest(http.MethodGet, url, http.NoBody)
 	if err != nil {
 		responder.RespondWithProblem(http.StatusInternalServerError, "Could not build request")
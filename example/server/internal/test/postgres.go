@@ -1,40 +1,89 @@
 package test
 
 import (
+	"database/sql/driver"
+	"fmt"
 	"net/http"
 
+	"github.com/BlackBX/service-framework/postgres"
+	"github.com/BlackBX/service-framework/request"
 	"github.com/BlackBX/service-framework/response"
 	"github.com/jmoiron/sqlx"
-	"go.uber.org/zap"
 )
 
+// DatabaseUnavailable is the problem type of requests that could not connect to
+// the database
+var DatabaseUnavailable = response.ProblemType{
+	Type:        "https://example.com/problems/database-unavailable",
+	Title:       "Database Unavailable",
+	Status:      http.StatusServiceUnavailable,
+	Description: "The database could not be reached, the request can be retried",
+}
+
+// NewDatabaseUnavailableMapping maps the bad connections to the database to the
+// DatabaseUnavailable problem type
+func NewDatabaseUnavailableMapping() response.ErrorMapping {
+	return response.ErrorMapping{Err: driver.ErrBadConn, Detail: "DATABASE_UNAVAILABLE", Type: &DatabaseUnavailable}
+}
+
+// numberPageOptions are the options of the pages of numbers
+var numberPageOptions = request.PageOptions{
+	MaxLimit:    100,
+	Sortable:    []string{"number"},
+	DefaultSort: []request.SortField{{Field: "number"}},
+}
+
 // PGHandler is the handler that communicates with a postgres database
 type PGHandler struct {
 	DB               *sqlx.DB
-	logger           *zap.Logger
 	ResponseProvider response.ResponderProvider
 }
 
 // NewPGHandler is a function that creates a new instance of the PGHandler type
-func NewPGHandler(db *sqlx.DB, logger *zap.Logger, responseProvider response.ResponderProvider) PGHandler {
-	return PGHandler{DB: db, logger: logger, ResponseProvider: responseProvider}
+func NewPGHandler(db *sqlx.DB, responseProvider response.ResponderProvider) PGHandler {
+	return PGHandler{DB: db, ResponseProvider: responseProvider}
 }
 
 // Get is a function that is called to pull data from the database
-func (h PGHandler) Get(w http.ResponseWriter, r *http.Request) {
-	responder := h.ResponseProvider.Responder(w, r)
+func (h PGHandler) Get(w http.ResponseWriter, r *http.Request) error {
 	res := &DBResponse{}
-	err := h.DB.GetContext(r.Context(), res, "SELECT 1 + 1 as result")
-
-	if err != nil {
-		h.logger.Error("The database is borked", zap.Error(err))
-		responder.RespondWithProblem(http.StatusInternalServerError, "The database is broken :(")
-		return
+	if err := h.DB.GetContext(r.Context(), res, "SELECT 1 + 1 as result"); err != nil {
+		return fmt.Errorf("could not query the database, got error (%w)", err)
 	}
-	responder.Respond(http.StatusOK, res)
+	h.ResponseProvider.Responder(w, r).Respond(http.StatusOK, res)
+	return nil
 }
 
 // DBResponse is the model that represents the response from the database
 type DBResponse struct {
 	Result int64 `json:"result" db:"result"`
 }
+
+// List responds with a page of the numbers that are generated by the database
+func (h PGHandler) List(w http.ResponseWriter, r *http.Request) error {
+	page, err := request.ParsePage(r, numberPageOptions)
+	if err != nil {
+		return err
+	}
+	keyset := postgres.Keyset{Limit: page.Limit, Cursor: page.Cursor}
+	for _, field := range page.Sort {
+		keyset.Columns = append(keyset.Columns, postgres.KeysetColumn{Name: field.Field, Descending: field.Descending})
+	}
+	var numbers []NumberModel
+	next, err := keyset.Select(r.Context(), h.DB, &numbers, "SELECT n AS number FROM generate_series(1, 1000) AS n")
+	if err != nil {
+		return fmt.Errorf("could not list the numbers, got error (%w)", err)
+	}
+	h.ResponseProvider.Responder(w, r).Respond(http.StatusOK, response.Page{
+		Items:      numbers,
+		Limit:      page.Limit,
+		HasMore:    next != "",
+		NextCursor: next,
+	})
+	return nil
+}
+
+// NumberModel is the model that represents a number generated by the database
+type NumberModel struct {
+	Number int64 `json:"number" db:"number"`
+}
@@ -3,6 +3,7 @@ package test
 import (
 	"net/http"
 
+	"github.com/BlackBX/service-framework/response"
 	"github.com/BlackBX/service-framework/router"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -18,6 +19,16 @@ var Module = fx.Provide(
 		Group:  "server",
 		Target: RegisterHandler,
 	},
+	fx.Annotated{
+		Group: "problem-types",
+		Target: func() response.ProblemType {
+			return DatabaseUnavailable
+		},
+	},
+	fx.Annotated{
+		Group:  "error-mappings",
+		Target: NewDatabaseUnavailableMapping,
+	},
 )
 
 // HandlerParams is the type that defines the parameters that are
@@ -28,6 +39,7 @@ type HandlerParams struct {
 	RedisHandler RedisHandler
 	PGHandler    PGHandler
 	HTTPHandler  HTTPHandler
+	ErrorHandler response.ErrorHandler
 }
 
 // RegisterHandler registers the handlers to the router
@@ -37,13 +49,41 @@ func RegisterHandler(params HandlerParams) router.Module {
 		Router: func(router *mux.Router) {
 			router.Handle("/redis", handlers.MethodHandler{
 				http.MethodGet: http.HandlerFunc(params.RedisHandler.Get),
-			})
+			}).Name("redis")
 			router.Handle("/pg", handlers.MethodHandler{
-				http.MethodGet: http.HandlerFunc(params.PGHandler.Get),
-			})
+				http.MethodGet: params.ErrorHandler.Handle(params.PGHandler.Get),
+			}).Name("pg")
+			router.Handle("/pg/numbers", handlers.MethodHandler{
+				http.MethodGet: params.ErrorHandler.Handle(params.PGHandler.List),
+			}).Name("pg-numbers")
 			router.Handle("/http/{id}", handlers.MethodHandler{
 				http.MethodGet: http.HandlerFunc(params.HTTPHandler.Get),
-			})
+			}).Name("http")
+		},
+		Operations: []router.Operation{
+			{
+				Route:   "redis",
+				Method:  http.MethodGet,
+				Summary: "Reads a value from redis",
+			},
+			{
+				Route:     "pg",
+				Method:    http.MethodGet,
+				Summary:   "Runs a query against postgres",
+				Responses: map[int]interface{}{http.StatusOK: DBResponse{}},
+			},
+			{
+				Route:     "pg-numbers",
+				Method:    http.MethodGet,
+				Summary:   "Lists a page of numbers generated by postgres",
+				Responses: map[int]interface{}{http.StatusOK: response.Page{Items: []NumberModel{}}},
+			},
+			{
+				Route:     "http",
+				Method:    http.MethodGet,
+				Summary:   "Fetches a todo from an external service",
+				Responses: map[int]interface{}{http.StatusOK: TodoModel{}},
+			},
 		},
 	}
 }
@@ -6,6 +6,8 @@ require (
 	github.com/AlekSi/pointer v1.1.0
 	github.com/NYTimes/gizmo v1.3.6
 	github.com/NYTimes/gziphandler v1.1.1
+	github.com/aws/aws-sdk-go v1.31.3
+	github.com/fsnotify/fsnotify v1.4.7
 	github.com/go-redis/redis/v7 v7.4.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
@@ -20,14 +22,17 @@ require (
 	github.com/prometheus/client_golang v1.3.0 // indirect
 	github.com/prometheus/common v0.9.1 // indirect
 	github.com/spf13/afero v1.2.2 // indirect
-	github.com/spf13/cast v1.3.1 // indirect
+	github.com/spf13/cast v1.3.1
 	github.com/spf13/cobra v0.0.7
 	github.com/spf13/jwalterweatherman v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.5
 	github.com/spf13/viper v1.7.1
+	github.com/vmihailenco/msgpack/v5 v5.3.5
 	go.uber.org/atomic v1.8.0
 	go.uber.org/fx v1.13.1
 	go.uber.org/zap v1.18.1
-	gopkg.in/DATA-DOG/go-sqlmock.v1 v1.3.0 // indirect
+	golang.org/x/net v0.0.0-20200501053045-e0ff5e5a1de5
+	google.golang.org/protobuf v1.23.0
+	gopkg.in/DATA-DOG/go-sqlmock.v1 v1.3.0
 	gopkg.in/ini.v1 v1.51.1 // indirect
 )
@@ -10,6 +10,7 @@ import (
 
 // Service allows the Health service to be registered with an application
 var Service = dependency.Service{
+	Name: "health",
 	Dependencies: fx.Provide(
 		healthcheck.NewHandler,
 	),
@@ -19,6 +20,19 @@ var Service = dependency.Service{
 	},
 }
 
+// AdminService registers the health checks with the admin server rather than the
+// public router, it replaces Service when used with admin.Service
+var AdminService = dependency.Service{
+	Name: "health",
+	Dependencies: fx.Provide(
+		healthcheck.NewHandler,
+	),
+	Constructor: fx.Annotated{
+		Group:  "admin",
+		Target: RegisterHealthcheck,
+	},
+}
+
 // RegisterHealthcheck registers the Healthcheck module with the router
 func RegisterHealthcheck(check healthcheck.Handler) router.Module {
 	return router.Module{
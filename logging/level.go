@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"fmt"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultLevels are the levels that each type of logger logs at when the
+// log-level has not been set
+var DefaultLevels = map[string]zapcore.Level{
+	"production":  zap.InfoLevel,
+	"development": zap.DebugLevel,
+	"nop":         zap.InfoLevel,
+}
+
+// NewLevel creates a new zap.AtomicLevel set from the configuration
+func NewLevel(settings dependency.ConfigGetter) (zap.AtomicLevel, error) {
+	level := zap.NewAtomicLevel()
+	if err := SetLevel(level, settings); err != nil {
+		return level, err
+	}
+	return level, nil
+}
+
+// SetLevel sets the level to the log-level from the configuration, or the
+// default level of the logger type if the log-level has not been set
+func SetLevel(level zap.AtomicLevel, settings dependency.ConfigGetter) error {
+	levelName := settings.GetString("log-level")
+	if levelName == "" {
+		defaultLevel, ok := DefaultLevels[settings.GetString("logger")]
+		if !ok {
+			defaultLevel = zap.InfoLevel
+		}
+		level.SetLevel(defaultLevel)
+		return nil
+	}
+	var newLevel zapcore.Level
+	if err := newLevel.UnmarshalText([]byte(levelName)); err != nil {
+		return fmt.Errorf("the log level (%s), is not a valid level", levelName)
+	}
+	level.SetLevel(newLevel)
+	return nil
+}
+
+// WatchLevel changes the level of the logger when the log-level is changed
+func WatchLevel(watcher *config.Watcher, level zap.AtomicLevel, logger *zap.Logger) {
+	watcher.Subscribe(func(settings dependency.ConfigGetter) {
+		if err := SetLevel(level, settings); err != nil {
+			logger.Error("Could not change the log level", zap.Error(err))
+			return
+		}
+		logger.Info("Changed the log level", zap.Stringer("level", level.Level()))
+	}, "log-level")
+}
+
+// levelCore is a zapcore.Core that decides which entries are logged using a
+// zap.AtomicLevel, the entries must also be enabled by the core that it wraps
+type levelCore struct {
+	zapcore.Core
+	level zap.AtomicLevel
+}
+
+func (c levelCore) Enabled(level zapcore.Level) bool {
+	return c.level.Enabled(level) && c.Core.Enabled(level)
+}
+
+func (c levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return levelCore{
+		Core:  c.Core.With(fields),
+		level: c.level,
+	}
+}
+
+func (c levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.level.Enabled(entry.Level) {
+		return checked
+	}
+	return c.Core.Check(entry, checked)
+}
@@ -0,0 +1,104 @@
+package logging_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/logging"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSetLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		logger        string
+		logLevel      string
+		expectedLevel zapcore.Level
+		expectError   bool
+	}{
+		{name: "production default", logger: "production", expectedLevel: zap.InfoLevel},
+		{name: "development default", logger: "development", expectedLevel: zap.DebugLevel},
+		{name: "set level", logger: "production", logLevel: "warn", expectedLevel: zap.WarnLevel},
+		{name: "invalid level", logger: "production", logLevel: "loud", expectError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := viper.New()
+			settings.Set("logger", test.logger)
+			settings.Set("log-level", test.logLevel)
+			level := zap.NewAtomicLevel()
+			err := logging.SetLevel(level, settings)
+			if test.expectError {
+				if err == nil {
+					t.Fatal("expected an error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error, got (%s)", err)
+			}
+			if level.Level() != test.expectedLevel {
+				t.Fatalf("expected level (%s), got (%s)", test.expectedLevel, level.Level())
+			}
+		})
+	}
+}
+
+func TestLoggerFactory_LevelledLogger(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	factory := logging.LoggerFactory{
+		LoggerConstructors: map[string]logging.LoggerConstructor{
+			"observed": func(options ...zap.Option) (*zap.Logger, error) {
+				return zap.New(core, options...), nil
+			},
+		},
+	}
+	settings := viper.New()
+	settings.Set("logger", "observed")
+	level := zap.NewAtomicLevel()
+	logger, err := factory.LevelledLogger(settings, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("hidden")
+	level.SetLevel(zap.DebugLevel)
+	logger.Debug("shown")
+	level.SetLevel(zap.ErrorLevel)
+	logger.Info("hidden")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Message != "shown" {
+		t.Fatalf("expected only the (shown) entry to be logged, got (%+v)", entries)
+	}
+}
+
+func TestLoggerFactory_LevelledLoggerWrapsCore(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	factory := logging.LoggerFactory{
+		LoggerConstructors: map[string]logging.LoggerConstructor{
+			"sampled": func(options ...zap.Option) (*zap.Logger, error) {
+				return zap.New(zapcore.NewSampler(core, time.Minute, 1, 100), options...), nil
+			},
+		},
+	}
+	settings := viper.New()
+	settings.Set("logger", "sampled")
+	level := zap.NewAtomicLevelAt(zap.DebugLevel)
+	logger, err := factory.LevelledLogger(settings, level)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("below the level of the core")
+	logger.Info("sampled")
+	logger.Info("sampled")
+
+	entries := logs.AllUntimed()
+	if len(entries) != 1 || entries[0].Message != "sampled" {
+		t.Fatalf("expected only the first (sampled) entry to be logged, got (%+v)", entries)
+	}
+}
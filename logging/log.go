@@ -8,15 +8,19 @@ import (
 	"github.com/BlackBX/service-framework/dependency"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // Service is the exported variable that can be used by the framework package
 var Service = dependency.Service{
+	Name:     "logging",
+	Requires: []string{"config"},
 	Dependencies: fx.Provide(
+		NewLevel,
 		NewPrintLogger,
 		fx.Annotated{
 			Group:  "middleware",
-			Target: NewMidlleware,
+			Target: NewRouterMiddleware,
 		},
 	),
 	ConfigFunc: func(set dependency.FlagSet) {
@@ -24,19 +28,26 @@ var Service = dependency.Service{
 		set.String("app-version", "dev", "The version of the application being configured")
 		set.String("environment", "test", "The environment that the application is deployed in")
 		set.String("logger", "development", "Whether to log in development mode.")
+		set.String("log-level", "", "The level to log at, defaults to the level of the logger type")
 		set.StringSlice("excluded-headers", []string{"Authorization"}, "Which headers to hide from the request log")
 	},
-	Constructor: NewLoggerFactory().Logger,
+	Constructor: NewLoggerFactory().LevelledLogger,
+	InvokeFunc:  WatchLevel,
 }
 
-// LoggerConstructor is a type that can give you an instance of a logger
+// LoggerConstructor is a type that can give you an instance of a logger, the
+// level of the logger limits the levels that the log-level can be set to
 type LoggerConstructor func(options ...zap.Option) (*zap.Logger, error)
 
 // NewLoggerFactory will create a new instance of a logger factory
 func NewLoggerFactory() LoggerFactory {
 	return LoggerFactory{
 		LoggerConstructors: map[string]LoggerConstructor{
-			"production":  zap.NewProduction,
+			"production": func(options ...zap.Option) (*zap.Logger, error) {
+				productionConfig := zap.NewProductionConfig()
+				productionConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+				return productionConfig.Build(options...)
+			},
 			"development": zap.NewDevelopment,
 			"nop": func(options ...zap.Option) (logger *zap.Logger, err error) {
 				return zap.NewNop(), nil
@@ -52,7 +63,23 @@ type LoggerFactory struct {
 
 // Logger creates a new instance of a *zap.Logger
 func (f LoggerFactory) Logger(settings dependency.ConfigGetter) (*zap.Logger, error) {
+	level, err := NewLevel(settings)
+	if err != nil {
+		return nil, err
+	}
+	return f.LevelledLogger(settings, level)
+}
+
+// LevelledLogger creates a new instance of a *zap.Logger that logs at the given
+// level, the production logger is sampled at every level
+func (f LoggerFactory) LevelledLogger(settings dependency.ConfigGetter, level zap.AtomicLevel) (*zap.Logger, error) {
 	options := []zap.Option{
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return levelCore{
+				Core:  core,
+				level: level,
+			}
+		}),
 		zap.Fields(
 			zap.String("app-name", settings.GetString("app-name")),
 			zap.String("app-version", settings.GetString("app-version")),
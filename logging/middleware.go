@@ -3,8 +3,12 @@ package logging
 import (
 	"fmt"
 	"net/http"
+	"sync/atomic"
 
+	"github.com/BlackBX/service-framework/config"
 	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
@@ -32,8 +36,45 @@ func (l *ResponseLogger) WriteHeader(statusCode int) {
 	l.ResponseWriter.WriteHeader(statusCode)
 }
 
+// Flush sends any buffered data to the client, if the base ResponseWriter
+// supports flushing
+func (l *ResponseLogger) Flush() {
+	if flusher, ok := l.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// NewRouterMiddleware returns the request logging middleware with the priority
+// of logging middleware
+func NewRouterMiddleware(logger *zap.Logger, settings dependency.ConfigGetter, watcher *config.Watcher) router.Middleware {
+	return router.Middleware{
+		Name:       "logging",
+		Priority:   router.PriorityLogging,
+		Middleware: NewWatchedMiddleware(logger, settings, watcher),
+	}
+}
+
 // NewMiddleware returns you a new instance of the Logger middleware
 func NewMidlleware(logger *zap.Logger, config dependency.ConfigGetter) mux.MiddlewareFunc {
+	return newMiddleware(logger, func() []string {
+		return config.GetStringSlice("excluded-headers")
+	})
+}
+
+// NewWatchedMiddleware returns you a new instance of the Logger middleware, the
+// excluded headers are updated when the configuration is reloaded
+func NewWatchedMiddleware(logger *zap.Logger, settings dependency.ConfigGetter, watcher *config.Watcher) mux.MiddlewareFunc {
+	excludedHeaders := &atomic.Value{}
+	excludedHeaders.Store(settings.GetStringSlice("excluded-headers"))
+	watcher.Subscribe(func(settings dependency.ConfigGetter) {
+		excludedHeaders.Store(settings.GetStringSlice("excluded-headers"))
+	}, "excluded-headers")
+	return newMiddleware(logger, func() []string {
+		return excludedHeaders.Load().([]string)
+	})
+}
+
+func newMiddleware(logger *zap.Logger, excludedHeaders func() []string) mux.MiddlewareFunc {
 	return func(handler http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			fields := []zap.Field{
@@ -43,7 +84,10 @@ func NewMidlleware(logger *zap.Logger, config dependency.ConfigGetter) mux.Middl
 				zap.String("protocol", r.Proto),
 				zap.Int64("request.content-length", r.ContentLength),
 			}
-			fields = append(fields, requestHeaders(r, config.GetStringSlice("excluded-headers"))...)
+			if id := response.RequestID(r); id != "" {
+				fields = append(fields, zap.String("request-id", id))
+			}
+			fields = append(fields, requestHeaders(r, excludedHeaders())...)
 			fields = append(fields, queryParams(r)...)
 			responseLogger := NewResponseLogger(rw)
 			handler.ServeHTTP(responseLogger, r)
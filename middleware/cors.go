@@ -2,7 +2,9 @@ package middleware
 
 import (
 	"net/http"
+	"sync/atomic"
 
+	"github.com/BlackBX/service-framework/config"
 	"github.com/BlackBX/service-framework/dependency"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -12,7 +14,8 @@ import (
 // CORSService allows the the cors middleware to be registered
 // with an application
 var CORSService = dependency.Service{
-	Name: "cors",
+	Name:     "cors",
+	Requires: []string{"config"},
 	ConfigFunc: func(set dependency.FlagSet) {
 		set.StringSlice(
 			"cors-allowed-headers",
@@ -67,22 +70,47 @@ var CORSService = dependency.Service{
 	},
 	Constructor: fx.Annotated{
 		Group:  "middleware",
-		Target: NewCORS,
+		Target: NewWatchedCORS,
 	},
 }
 
 // NewCORS creates a new cors middleware configured from the app
 func NewCORS(config dependency.ConfigGetter) mux.MiddlewareFunc {
+	return newCORS(config)
+}
+
+// NewWatchedCORS creates a new cors middleware configured from the app, the
+// middleware is reconfigured when the CORS configuration is reloaded
+func NewWatchedCORS(settings dependency.ConfigGetter, watcher *config.Watcher) mux.MiddlewareFunc {
+	current := &atomic.Value{}
+	current.Store(newCORS(settings))
+	watcher.Subscribe(
+		func(settings dependency.ConfigGetter) {
+			current.Store(newCORS(settings))
+		},
+		"cors-allowed-headers",
+		"cors-allowed-methods",
+		"cors-allowed-origins",
+		"cors-allow-credentials",
+	)
+	return func(handler http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			middleware := current.Load().(mux.MiddlewareFunc)
+			middleware(handler).ServeHTTP(rw, r)
+		})
+	}
+}
+
+func newCORS(settings dependency.ConfigGetter) mux.MiddlewareFunc {
 	baseOptions := []handlers.CORSOption{
-		handlers.AllowedHeaders(config.GetStringSlice("cors-allowed-headers")),
-		handlers.AllowedMethods(config.GetStringSlice("cors-allowed-methods")),
-		handlers.AllowedOrigins(config.GetStringSlice("cors-allowed-origins")),
+		handlers.AllowedHeaders(settings.GetStringSlice("cors-allowed-headers")),
+		handlers.AllowedMethods(settings.GetStringSlice("cors-allowed-methods")),
+		handlers.AllowedOrigins(settings.GetStringSlice("cors-allowed-origins")),
 	}
 	options := make([]handlers.CORSOption, 0, 4)
-	if config.GetBool("cors-allow-credentials") {
+	if settings.GetBool("cors-allow-credentials") {
 		options = append(options, handlers.AllowCredentials())
 	}
 	options = append(options, baseOptions...)
-	middleware := handlers.CORS(options...)
-	return middleware
+	return handlers.CORS(options...)
 }
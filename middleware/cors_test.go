@@ -2,8 +2,11 @@ package middleware_test
 
 import (
 	"context"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
@@ -14,6 +17,7 @@ import (
 	"github.com/BlackBX/service-framework/middleware"
 	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 	"go.uber.org/fx"
 )
 
@@ -124,3 +128,69 @@ func TestNewCORSPreflight(t *testing.T) {
 	time.Sleep(time.Millisecond * 100)
 	cancel()
 }
+
+func TestNewWatchedCORSReload(t *testing.T) {
+	directory, err := ioutil.TempDir("", "cors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+	file := filepath.Join(directory, "config.yaml")
+	if err := ioutil.WriteFile(file, []byte("cors-allowed-origins: [localhost]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := &cobra.Command{}
+	config.Service.ConfigFunc(cmd.Flags())
+	middleware.CORSService.ConfigFunc(cmd.Flags())
+	if err := cmd.Flags().Set("config", file); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store, err := config.NewStore(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watcher := config.NewWatcher(store, cmd)
+	handler := middleware.NewWatchedCORS(store, watcher)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	allowedOrigin := func() string {
+		request := httptest.NewRequest(http.MethodGet, "http://stampede.ai", http.NoBody)
+		request.Header.Add("Origin", "stampede.ai")
+		response := httptest.NewRecorder()
+		handler.ServeHTTP(response, request)
+		return response.Header().Get("Access-Control-Allow-Origin")
+	}
+
+	if origin := allowedOrigin(); origin != "" {
+		t.Fatalf("expected the origin not to be allowed, got (%s)", origin)
+	}
+	if err := ioutil.WriteFile(file, []byte("cors-allowed-origins: [stampede.ai]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if origin := allowedOrigin(); origin != "stampede.ai" {
+		t.Fatalf("expected the origin to be allowed after reloading, got (%s)", origin)
+	}
+}
+
+func TestNewCORS(t *testing.T) {
+	settings := viper.New()
+	settings.Set("cors-allowed-origins", []string{"stampede.ai"})
+	handler := middleware.NewCORS(settings)(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+	request := httptest.NewRequest(http.MethodGet, "http://stampede.ai", http.NoBody)
+	request.Header.Add("Origin", "stampede.ai")
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	if origin := response.Header().Get("Access-Control-Allow-Origin"); origin != "stampede.ai" {
+		t.Fatalf("expected the origin to be allowed, got (%s)", origin)
+	}
+}
@@ -2,31 +2,58 @@ package middleware
 
 import (
 	"github.com/BlackBX/service-framework/logging"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
 	"github.com/NYTimes/gziphandler"
 	"github.com/gorilla/handlers"
-	"github.com/gorilla/mux"
 	"github.com/newrelic/go-agent/v3/integrations/nrgorilla"
+	"github.com/newrelic/go-agent/v3/newrelic"
 	"go.uber.org/fx"
 )
 
-// Module allows the default middlewares to be registered to an app
+// Module allows the default middlewares to be registered to an app, the request
+// ID wraps recovery, which wraps tracing, which wraps compression
 var Module = fx.Provide(
 	fx.Annotated{
 		Group: "middleware",
-		Target: func(logger logging.PrintLogger) mux.MiddlewareFunc {
-			return handlers.RecoveryHandler(
-				handlers.RecoveryLogger(logger),
-			)
+		Target: func() router.Middleware {
+			return router.Middleware{
+				Name:       "request-id",
+				Priority:   router.PriorityRequestID,
+				Middleware: response.RequestIDMiddleware,
+			}
 		},
 	},
 	fx.Annotated{
 		Group: "middleware",
-		Target: func() mux.MiddlewareFunc {
-			return gziphandler.GzipHandler
+		Target: func(logger logging.PrintLogger) router.Middleware {
+			return router.Middleware{
+				Name:     "recovery",
+				Priority: router.PriorityRecovery,
+				Middleware: handlers.RecoveryHandler(
+					handlers.RecoveryLogger(logger),
+				),
+			}
 		},
 	},
 	fx.Annotated{
-		Group:  "middleware",
-		Target: nrgorilla.Middleware,
+		Group: "middleware",
+		Target: func() router.Middleware {
+			return router.Middleware{
+				Name:       "gzip",
+				Priority:   router.PriorityCompression,
+				Middleware: gziphandler.GzipHandler,
+			}
+		},
+	},
+	fx.Annotated{
+		Group: "middleware",
+		Target: func(app *newrelic.Application) router.Middleware {
+			return router.Middleware{
+				Name:       "newrelic",
+				Priority:   router.PriorityTracing,
+				Middleware: nrgorilla.Middleware(app),
+			}
+		},
 	},
 )
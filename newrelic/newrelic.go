@@ -14,6 +14,8 @@ import (
 
 // Service allows newrelic to be added to an application, it adds the middleware aswell too
 var Service = dependency.Service{
+	Name:     "newrelic",
+	Requires: []string{"config", "logging"},
 	Dependencies: fx.Provide(
 		fx.Annotated{
 			Group: "trippers",
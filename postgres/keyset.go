@@ -0,0 +1,160 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/jmoiron/sqlx"
+	"github.com/jmoiron/sqlx/reflectx"
+)
+
+// ErrInvalidCursor is wrapped by the errors of the cursors of a Keyset that cannot
+// be decoded, or that are not in the order of its columns
+var ErrInvalidCursor = response.ErrInvalidCursor
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// KeysetColumn is a column that a keyset paginated query is ordered by
+type KeysetColumn struct {
+	Name       string
+	Descending bool
+}
+
+// Keyset paginates a query by the values of the columns that it is ordered by,
+// the page starts after the row of the Cursor. The columns must be columns of the
+// query that are not null, and the last column must be unique, such as the
+// primary key, so that the rows are in a stable order.
+type Keyset struct {
+	Columns []KeysetColumn
+	Limit   int
+	Cursor  string
+}
+
+// Query wraps the query so that it selects the page after the cursor, in the order
+// of the columns. The values of the cursor and the limit are bound as parameters
+// after the args of the query, which must use $1 style parameters. One more row
+// than the Limit is selected, so that it is known whether there is a next page.
+// When the cursor is not valid the error is a response.CursorError, which is a
+// bad request problem.
+func (k Keyset) Query(query string, args ...interface{}) (string, []interface{}, error) {
+	if len(k.Columns) == 0 {
+		return "", nil, errors.New("could not paginate the query without columns")
+	}
+	if k.Limit < 1 {
+		return "", nil, fmt.Errorf("could not paginate the query with the limit (%d)", k.Limit)
+	}
+	order := make([]string, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		if !identifier.MatchString(column.Name) {
+			return "", nil, fmt.Errorf("could not paginate the query by the column (%s)", column.Name)
+		}
+		direction := "ASC"
+		if column.Descending {
+			direction = "DESC"
+		}
+		order = append(order, fmt.Sprintf("%s %s", quoteIdentifier(column.Name), direction))
+	}
+	values, err := k.cursorValues()
+	if err != nil {
+		return "", nil, err
+	}
+	args = append(append([]interface{}{}, args...), values...)
+	where := ""
+	if len(values) > 0 {
+		where = fmt.Sprintf(" WHERE %s", k.after(len(args)-len(values)+1))
+	}
+	args = append(args, k.Limit+1)
+	paginated := fmt.Sprintf(
+		"SELECT * FROM (%s) AS keyset_page%s ORDER BY %s LIMIT $%d",
+		query, where, strings.Join(order, ", "), len(args),
+	)
+	return paginated, args, nil
+}
+
+// after builds the condition of the rows after the cursor, a row is after the
+// cursor when it is equal to it in the leading columns, and after it in the next
+func (k Keyset) after(firstParam int) string {
+	conditions := make([]string, 0, len(k.Columns))
+	for i, column := range k.Columns {
+		terms := make([]string, 0, i+1)
+		for j, equal := range k.Columns[:i] {
+			terms = append(terms, fmt.Sprintf("%s = $%d", quoteIdentifier(equal.Name), firstParam+j))
+		}
+		operator := ">"
+		if column.Descending {
+			operator = "<"
+		}
+		terms = append(terms, fmt.Sprintf("%s %s $%d", quoteIdentifier(column.Name), operator, firstParam+i))
+		conditions = append(conditions, fmt.Sprintf("(%s)", strings.Join(terms, " AND ")))
+	}
+	return strings.Join(conditions, " OR ")
+}
+
+// fields gives the columns as the fields of a cursor
+func (k Keyset) fields() []response.CursorField {
+	fields := make([]response.CursorField, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		fields = append(fields, response.CursorField{Name: column.Name, Descending: column.Descending})
+	}
+	return fields
+}
+
+// cursorValues decodes the values of the columns from the cursor, when the cursor
+// is not valid the error is a response.CursorError
+func (k Keyset) cursorValues() ([]interface{}, error) {
+	if k.Cursor == "" {
+		return nil, nil
+	}
+	cursor, err := response.DecodeCursor(k.Cursor, k.fields())
+	if err != nil {
+		return nil, err
+	}
+	return cursor.Values, nil
+}
+
+// EncodeCursor encodes the values of the columns of a row as a cursor, the values
+// are in the order of the columns of the Keyset
+func (k Keyset) EncodeCursor(values ...interface{}) (string, error) {
+	return response.Cursor{Fields: k.fields(), Values: values}.Encode()
+}
+
+// Select selects the page of the query into dest, which must be a pointer to a
+// slice of structs, the columns are mapped to fields by their db tags. It returns
+// the cursor of the next page, which is empty when it is the last page.
+func (k Keyset) Select(ctx context.Context, db sqlx.QueryerContext, dest interface{}, query string, args ...interface{}) (string, error) {
+	paginated, args, err := k.Query(query, args...)
+	if err != nil {
+		return "", err
+	}
+	if err := sqlx.SelectContext(ctx, db, dest, paginated, args...); err != nil {
+		return "", fmt.Errorf("could not select the page, got error (%w)", err)
+	}
+	rows := reflect.Indirect(reflect.ValueOf(dest))
+	if rows.Len() <= k.Limit {
+		return "", nil
+	}
+	rows.Set(rows.Slice(0, k.Limit))
+	last := reflect.Indirect(rows.Index(k.Limit - 1))
+	names := make([]string, 0, len(k.Columns))
+	for _, column := range k.Columns {
+		names = append(names, column.Name)
+	}
+	mapper := reflectx.NewMapperFunc("db", sqlx.NameMapper)
+	values := make([]interface{}, 0, len(names))
+	for i, traversal := range mapper.TraversalsByName(last.Type(), names) {
+		if len(traversal) == 0 {
+			return "", fmt.Errorf("could not find the column (%s) in (%s)", names[i], last.Type())
+		}
+		values = append(values, reflectx.FieldByIndexesReadOnly(last, traversal).Interface())
+	}
+	return k.EncodeCursor(values...)
+}
+
+func quoteIdentifier(name string) string {
+	return fmt.Sprintf(`"%s"`, name)
+}
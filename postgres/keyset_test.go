@@ -0,0 +1,126 @@
+package postgres_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/BlackBX/service-framework/postgres"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/jmoiron/sqlx"
+	sqlmock "gopkg.in/DATA-DOG/go-sqlmock.v1"
+)
+
+func TestKeysetQuery(t *testing.T) {
+	keyset := postgres.Keyset{
+		Columns: []postgres.KeysetColumn{{Name: "created", Descending: true}, {Name: "id"}},
+		Limit:   10,
+	}
+	cursor, err := keyset.EncodeCursor("2020-01-02T00:00:00Z", 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyset.Cursor = cursor
+	query, args, err := keyset.Query("SELECT id, created FROM todos WHERE status = $1", "open")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `SELECT * FROM (SELECT id, created FROM todos WHERE status = $1) AS keyset_page ` +
+		`WHERE ("created" < $2) OR ("created" = $2 AND "id" > $3) ORDER BY "created" DESC, "id" ASC LIMIT $4`
+	if query != expected {
+		t.Fatalf("expected the query (%s), got (%s)", expected, query)
+	}
+	expectedArgs := []interface{}{"open", "2020-01-02T00:00:00Z", json.Number("12"), 11}
+	if !reflect.DeepEqual(expectedArgs, args) {
+		t.Fatalf("expected the args (%#v), got (%#v)", expectedArgs, args)
+	}
+}
+
+func TestKeysetQueryErrors(t *testing.T) {
+	tests := map[string]postgres.Keyset{
+		"no columns":     {Limit: 10},
+		"no limit":       {Columns: []postgres.KeysetColumn{{Name: "id"}}},
+		"unsafe column":  {Columns: []postgres.KeysetColumn{{Name: `id"; DROP TABLE todos; --`}}, Limit: 10},
+		"invalid cursor": {Columns: []postgres.KeysetColumn{{Name: "id"}}, Limit: 10, Cursor: "not a cursor"},
+	}
+	for name, keyset := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := keyset.Query("SELECT id FROM todos"); err == nil {
+				t.Fatal("expected an error")
+			}
+		})
+	}
+}
+
+func TestKeysetQueryCursorOfAnotherOrder(t *testing.T) {
+	descending := postgres.Keyset{Columns: []postgres.KeysetColumn{{Name: "id", Descending: true}}, Limit: 10}
+	cursor, err := descending.EncodeCursor(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyset := postgres.Keyset{Columns: []postgres.KeysetColumn{{Name: "id"}}, Limit: 10, Cursor: cursor}
+	_, _, err = keyset.Query("SELECT id FROM todos")
+	if !errors.Is(err, postgres.ErrInvalidCursor) {
+		t.Fatalf("expected the error (%v), got (%v)", postgres.ErrInvalidCursor, err)
+	}
+	problem, ok := response.ErrorMapper{}.Problem(err)
+	if !ok || problem.Status != http.StatusBadRequest {
+		t.Fatalf("expected a bad request problem, got (%+v)", problem)
+	}
+}
+
+func TestKeysetQueryCursorOfObjects(t *testing.T) {
+	keyset := postgres.Keyset{Columns: []postgres.KeysetColumn{{Name: "created"}, {Name: "id"}}, Limit: 10}
+	for name, value := range map[string]interface{}{
+		"object": map[string]interface{}{"id": 1},
+		"array":  []interface{}{1, 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			cursor, err := keyset.EncodeCursor("2020-01-02T00:00:00Z", value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyset.Cursor = cursor
+			_, _, err = keyset.Query("SELECT id FROM todos")
+			problem, ok := response.ErrorMapper{}.Problem(err)
+			if !errors.Is(err, postgres.ErrInvalidCursor) || !ok || problem.Status != http.StatusBadRequest {
+				t.Fatalf("expected a bad request problem, got (%+v) with error (%v)", problem, err)
+			}
+		})
+	}
+}
+
+type todo struct {
+	ID    int64  `db:"id"`
+	Title string `db:"title"`
+}
+
+func TestKeysetSelect(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM (SELECT id, title FROM todos) AS keyset_page ORDER BY "id" ASC LIMIT $1`)).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title"}).AddRow(1, "a").AddRow(2, "b").AddRow(3, "c"))
+	keyset := postgres.Keyset{Columns: []postgres.KeysetColumn{{Name: "id"}}, Limit: 2}
+	var todos []todo
+	next, err := keyset.Select(context.Background(), sqlx.NewDb(db, "postgres"), &todos, "SELECT id, title FROM todos")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []todo{{ID: 1, Title: "a"}, {ID: 2, Title: "b"}}; !reflect.DeepEqual(expected, todos) {
+		t.Fatalf("expected the todos (%+v), got (%+v)", expected, todos)
+	}
+	if expected, _ := keyset.EncodeCursor(2); next != expected {
+		t.Fatalf("expected the next cursor (%s), got (%s)", expected, next)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/BlackBX/service-framework/config"
 	"github.com/BlackBX/service-framework/dependency"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/jmoiron/sqlx"
@@ -15,18 +16,10 @@ import (
 
 // Service defines the configuration and constructors required to get a postgres *sql.DB
 var Service = dependency.Service{
+	Name:     "postgres",
+	Requires: []string{"config", "health"},
 	ConfigFunc: func(set dependency.FlagSet) {
-		set.String("postgres-dbname", "postgres", "The name of the database to connect to")
-		set.String("postgres-user", "postgres", "The name of the user to connect to the postgres db with")
-		set.String("postgres-password", "", "The password to connect to the postgres database")
-		set.String("postgres-host", "localhost", "The host to connect to the postgres database on")
-		set.Int("postgres-port", 5432, "The port to connect to the postgres database on")
-		set.String("postgres-sslmode", "disable", "What sslmode to use with the postgres database")
-		set.String("postgres-fallback-application-name", "", "An application_name for postgres to fall back to if one isn't provided.")
-		set.Duration("postgres-connect-timeout", 0, "Maximum wait for connection, 0 means wait indefinitely")
-		set.String("postgres-sslcert", "", "Cert file location. The file must contain PEM encoded data.")
-		set.String("postgres-sslkey", "", "Key file location. The file must contain PEM encoded data.")
-		set.String("postgres-sslrootkey", "", "The location of the root certificate file. The file must contain PEM encoded data.")
+		config.RegisterFlags(set, Config{})
 	},
 	Dependencies: fx.Provide(
 		NewConfig, NewSQLX,
@@ -36,17 +29,17 @@ var Service = dependency.Service{
 
 // Config defines the configuration required to create a postgres database connection
 type Config struct {
-	DBName                  string
-	User                    string
-	Password                string
-	Host                    string
-	Port                    int
-	SSLMode                 string
-	FallbackApplicationName string
-	ConnectTimeout          time.Duration
-	SSLCert                 string
-	SSLKey                  string
-	SSLRootCert             string
+	DBName                  string        `flag:"postgres-dbname" default:"postgres" usage:"The name of the database to connect to"`
+	User                    string        `flag:"postgres-user" default:"postgres" usage:"The name of the user to connect to the postgres db with"`
+	Password                string        `flag:"postgres-password" usage:"The password to connect to the postgres database"`
+	Host                    string        `flag:"postgres-host" default:"localhost" usage:"The host to connect to the postgres database on" validate:"required"`
+	Port                    int           `flag:"postgres-port" default:"5432" usage:"The port to connect to the postgres database on" validate:"min=1,max=65535"`
+	SSLMode                 string        `flag:"postgres-sslmode" default:"disable" usage:"What sslmode to use with the postgres database" validate:"oneof=disable|allow|prefer|require|verify-ca|verify-full"`
+	FallbackApplicationName string        `flag:"postgres-fallback-application-name" usage:"An application_name for postgres to fall back to if one isn't provided."`
+	ConnectTimeout          time.Duration `flag:"postgres-connect-timeout" default:"0s" usage:"Maximum wait for connection, 0 means wait indefinitely" validate:"min=0s"`
+	SSLCert                 string        `flag:"postgres-sslcert" usage:"Cert file location. The file must contain PEM encoded data."`
+	SSLKey                  string        `flag:"postgres-sslkey" usage:"Key file location. The file must contain PEM encoded data."`
+	SSLRootCert             string        `flag:"postgres-sslrootkey" usage:"The location of the root certificate file. The file must contain PEM encoded data."`
 }
 
 func (c Config) sslStringParts() []string {
@@ -98,21 +91,14 @@ func (c Config) String() string {
 	return strings.Join(parameters, " ")
 }
 
-// NewConfig creates a new instance of the configuration from app configuration
-func NewConfig(config dependency.ConfigGetter) Config {
-	return Config{
-		DBName:                  config.GetString("postgres-dbname"),
-		User:                    config.GetString("postgres-user"),
-		Password:                config.GetString("postgres-password"),
-		Host:                    config.GetString("postgres-host"),
-		Port:                    config.GetInt("postgres-port"),
-		SSLMode:                 config.GetString("postgres-sslmode"),
-		FallbackApplicationName: config.GetString("postgres-fallback-application-name"),
-		ConnectTimeout:          config.GetDuration("postgres-connect-timeout"),
-		SSLCert:                 config.GetString("postgres-sslcert"),
-		SSLKey:                  config.GetString("postgres-sslkey"),
-		SSLRootCert:             config.GetString("postgres-sslrootkey"),
+// NewConfig creates a new instance of the configuration from app configuration,
+// returning an error if any of the configuration is invalid
+func NewConfig(getter dependency.ConfigGetter) (Config, error) {
+	pgConfig := Config{}
+	if err := config.Populate(getter, &pgConfig); err != nil {
+		return Config{}, fmt.Errorf("could not configure postgres, got error (%w)", err)
 	}
+	return pgConfig, nil
 }
 
 // NewFactory creates a new instance of a Factory that can create postgres *sql.DBs
@@ -71,12 +71,36 @@ func TestNewConfig(t *testing.T) {
 		t.Fatal(err)
 	}
 	expectedString := "dbname=postgres user=postgres host=localhost port=5432 sslmode=disable"
-	pgCfg := postgres.NewConfig(cfg)
+	pgCfg, err := postgres.NewConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
 	if pgCfg.String() != expectedString {
 		t.Fatalf("expected string to be (%s), got (%s)", expectedString, pgCfg)
 	}
 }
 
+func TestNewConfigInvalid(t *testing.T) {
+	cmd := &cobra.Command{}
+	postgres.Service.ConfigFunc(cmd.PersistentFlags())
+	cmd.SetArgs([]string{"--postgres-port=0", "--postgres-sslmode=sometimes"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = postgres.NewConfig(cfg)
+	validationError := config.ValidationError{}
+	if !errors.As(err, &validationError) {
+		t.Fatalf("expected a validation error, got (%v)", err)
+	}
+	if len(validationError.Fields) != 2 {
+		t.Fatalf("expected (2) invalid fields, got (%d)", len(validationError.Fields))
+	}
+}
+
 func TestNew(t *testing.T) {
 	cmd := &cobra.Command{}
 	postgres.Service.ConfigFunc(cmd.PersistentFlags())
@@ -87,7 +111,11 @@ func TestNew(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	_, err = postgres.NewFactory().DB(postgres.NewConfig(cfg), healthcheck.NewHandler())
+	pgCfg, err := postgres.NewConfig(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = postgres.NewFactory().DB(pgCfg, healthcheck.NewHandler())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -21,6 +21,8 @@ type Cmdable interface {
 
 // Service is the service to be used by the framework.Builder
 var Service = dependency.Service{
+	Name:     "redis",
+	Requires: []string{"config", "health"},
 	// nolint: gomnd
 	ConfigFunc: func(set dependency.FlagSet) {
 		set.String(
@@ -98,6 +100,11 @@ var Service = dependency.Service{
 			4*time.Second,
 			"Amount of time client waits for connection if all connections are busy before returning an error.",
 		)
+		set.Duration(
+			"redis-idle-timeout",
+			5*time.Minute,
+			"The amount of time after which idle connections are closed",
+		)
 		set.Duration(
 			"redis-idle-check-frequency",
 			time.Minute,
@@ -49,7 +49,7 @@ func TestNewOptions(t *testing.T) {
 		MinIdleConns:       0,
 		MaxConnAge:         parseDuration(t, "0s"),
 		PoolTimeout:        parseDuration(t, "4s"),
-		IdleTimeout:        parseDuration(t, "0s"),
+		IdleTimeout:        parseDuration(t, "5m0s"),
 		IdleCheckFrequency: parseDuration(t, "1m0s"),
 	}
 
@@ -0,0 +1,153 @@
+package request
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BlackBX/service-framework/response"
+)
+
+// DefaultLimit is the limit of a page when the request does not have a limit
+const DefaultLimit = 20
+
+// PageOptions are the options of the pagination of a collection, the Sortable
+// fields can be sorted by, and the Filterable fields can be filtered by. The
+// DefaultSort is used when the request is not sorted.
+type PageOptions struct {
+	DefaultLimit int
+	MaxLimit     int
+	Sortable     []string
+	Filterable   []string
+	DefaultSort  []SortField
+}
+
+// SortField is a field that a collection is sorted by
+type SortField struct {
+	Field      string
+	Descending bool
+}
+
+// PageRequest is the page of a collection that a request asks for
+type PageRequest struct {
+	Limit   int
+	Cursor  string
+	Offset  int
+	Sort    []SortField
+	Filters map[string][]string
+}
+
+// ParsePage parses the page of the collection that the request asks for from the
+// limit, cursor, offset and sort query parameters, and the query parameters of
+// the Filterable fields, for example:
+//
+//	/todos?limit=10&cursor=eyJpZCI6MTB9&sort=-created,id&status=open
+//
+// Fields are sorted in descending order when they start with a "-". The cursor
+// must be a response.Cursor of the fields of the sort, such as the cursor of a
+// postgres.Keyset with columns that are named as the fields. When the request is not
+// valid the error is a *response.Problem with the invalid-params of the request.
+func ParsePage(r *http.Request, options PageOptions) (PageRequest, error) {
+	query := r.URL.Query()
+	page := PageRequest{
+		Limit:  options.DefaultLimit,
+		Cursor: query.Get("cursor"),
+		Sort:   options.DefaultSort,
+	}
+	if page.Limit <= 0 {
+		page.Limit = DefaultLimit
+	}
+	var params []response.InvalidParam
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || limit < 1:
+			params = append(params, response.InvalidParam{Name: "limit", Reason: "must be a positive integer"})
+		case options.MaxLimit > 0 && limit > options.MaxLimit:
+			params = append(params, response.InvalidParam{Name: "limit", Reason: fmt.Sprintf("must be at most %d", options.MaxLimit)})
+		default:
+			page.Limit = limit
+		}
+	}
+	if raw := query.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		switch {
+		case err != nil || offset < 0:
+			params = append(params, response.InvalidParam{Name: "offset", Reason: "must be a positive integer"})
+		case page.Cursor != "":
+			params = append(params, response.InvalidParam{Name: "offset", Reason: "cannot be used with a cursor"})
+		default:
+			page.Offset = offset
+		}
+	}
+	if raw := query.Get("sort"); raw != "" {
+		sort, invalid := parseSort(raw, options.Sortable)
+		page.Sort = sort
+		params = append(params, invalid...)
+	}
+	if page.Cursor != "" {
+		params = append(params, checkCursor(page.Cursor, page.Sort)...)
+	}
+	for _, field := range options.Filterable {
+		if values, ok := query[field]; ok {
+			if page.Filters == nil {
+				page.Filters = map[string][]string{}
+			}
+			page.Filters[field] = values
+		}
+	}
+	if len(params) > 0 {
+		return PageRequest{}, response.NewInvalidParamsProblem(params)
+	}
+	return page, nil
+}
+
+// parseSort parses the comma separated fields of the sort query parameter, only
+// the sortable fields can be sorted by
+func parseSort(raw string, sortable []string) ([]SortField, []response.InvalidParam) {
+	var (
+		sort   []SortField
+		params []response.InvalidParam
+	)
+	for _, field := range strings.Split(raw, ",") {
+		sortField := SortField{Field: strings.TrimSpace(field)}
+		if strings.HasPrefix(sortField.Field, "-") {
+			sortField.Field = strings.TrimPrefix(sortField.Field, "-")
+			sortField.Descending = true
+		}
+		if !contains(sortable, sortField.Field) {
+			params = append(params, response.InvalidParam{
+				Name:   "sort",
+				Reason: fmt.Sprintf("cannot sort by (%s), must be one of (%s)", sortField.Field, strings.Join(sortable, ", ")),
+			})
+			continue
+		}
+		sort = append(sort, sortField)
+	}
+	return sort, params
+}
+
+// checkCursor checks that the cursor can be decoded, and is in the order of the sort
+func checkCursor(cursor string, sort []SortField) []response.InvalidParam {
+	fields := make([]response.CursorField, 0, len(sort))
+	for _, field := range sort {
+		fields = append(fields, response.CursorField{Name: field.Field, Descending: field.Descending})
+	}
+	_, err := response.DecodeCursor(cursor, fields)
+	var cursorErr response.CursorError
+	if errors.As(err, &cursorErr) {
+		return []response.InvalidParam{{Name: "cursor", Reason: cursorErr.Reason}}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package request_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/BlackBX/service-framework/request"
+	"github.com/BlackBX/service-framework/response"
+)
+
+func TestParsePage(t *testing.T) {
+	cursor, err := response.Cursor{
+		Fields: []response.CursorField{{Name: "created", Descending: true}, {Name: "id"}},
+		Values: []interface{}{"2020-01-02T00:00:00Z", 12},
+	}.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := request.PageOptions{
+		MaxLimit:    50,
+		Sortable:    []string{"created", "id"},
+		Filterable:  []string{"status"},
+		DefaultSort: []request.SortField{{Field: "id"}},
+	}
+	tests := map[string]struct {
+		query    string
+		expected request.PageRequest
+		params   []response.InvalidParam
+	}{
+		"defaults": {
+			expected: request.PageRequest{Limit: request.DefaultLimit, Sort: []request.SortField{{Field: "id"}}},
+		},
+		"cursor": {
+			query: "?limit=10&cursor=" + cursor + "&sort=-created,id&status=open&status=late&owner=me",
+			expected: request.PageRequest{
+				Limit:   10,
+				Cursor:  cursor,
+				Sort:    []request.SortField{{Field: "created", Descending: true}, {Field: "id"}},
+				Filters: map[string][]string{"status": {"open", "late"}},
+			},
+		},
+		"offset": {
+			query:    "?offset=40",
+			expected: request.PageRequest{Limit: request.DefaultLimit, Offset: 40, Sort: []request.SortField{{Field: "id"}}},
+		},
+		"invalid": {
+			query: "?limit=51&cursor=abc&offset=10&sort=title",
+			params: []response.InvalidParam{
+				{Name: "limit", Reason: "must be at most 50"},
+				{Name: "offset", Reason: "cannot be used with a cursor"},
+				{Name: "sort", Reason: "cannot sort by (title), must be one of (created, id)"},
+				{Name: "cursor", Reason: "is not a valid cursor"},
+			},
+		},
+		"cursor of another sort": {
+			query: "?cursor=" + cursor + "&sort=id",
+			params: []response.InvalidParam{
+				{Name: "cursor", Reason: "is not a cursor of the order of the page"},
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			page, err := request.ParsePage(httptest.NewRequest(http.MethodGet, "/todos"+test.query, nil), options)
+			if test.params != nil {
+				problem, ok := err.(*response.Problem)
+				if !ok || !reflect.DeepEqual(test.params, problem.InvalidParams) {
+					t.Fatalf("expected the invalid params (%+v), got error (%v)", test.params, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(test.expected, page) {
+				t.Fatalf("expected the page (%+v), got (%+v)", test.expected, page)
+			}
+		})
+	}
+}
@@ -0,0 +1,346 @@
+package request
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/gorilla/mux"
+)
+
+// DefaultMaxBodySize is the largest request body that is decoded by default, 1MiB
+const DefaultMaxBodySize = 1 << 20
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// Service provides a Decoder that responds with the problems of the requests
+// that cannot be decoded
+var Service = dependency.Service{
+	Name:     "request",
+	Requires: []string{"config", "response"},
+	ConfigFunc: func(flags dependency.FlagSet) {
+		flags.Int64("request-max-body-size", DefaultMaxBodySize, "The largest request body in bytes that will be decoded")
+	},
+	Constructor: NewDecoder,
+}
+
+// Decoder decodes requests into structs, and responds with a problem when a
+// request is not valid
+type Decoder struct {
+	ResponseProvider response.ResponderProvider
+	MaxBodySize      int64
+}
+
+// NewDecoder creates a new instance of the Decoder from the configuration
+func NewDecoder(provider response.ResponderProvider, getter dependency.ConfigGetter) Decoder {
+	return Decoder{
+		ResponseProvider: provider,
+		MaxBodySize:      getter.GetInt64("request-max-body-size"),
+	}
+}
+
+// Decode decodes the request into the struct that v points to, see Decode
+func (d Decoder) Decode(r *http.Request, v interface{}) error {
+	return decode(r, v, d.MaxBodySize)
+}
+
+// DecodeOrRespond decodes the request into the struct that v points to, when the
+// request cannot be decoded it responds with the problem, and returns false
+func (d Decoder) DecodeOrRespond(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := d.Decode(r, v)
+	if err == nil {
+		return true
+	}
+	d.respondWithError(w, r, err)
+	return false
+}
+
+// ParsePageOrRespond parses the page that the request asks for, see ParsePage,
+// when the request is not valid it responds with the problem, and returns false
+func (d Decoder) ParsePageOrRespond(w http.ResponseWriter, r *http.Request, options PageOptions) (PageRequest, bool) {
+	page, err := ParsePage(r, options)
+	if err != nil {
+		d.respondWithError(w, r, err)
+		return PageRequest{}, false
+	}
+	return page, true
+}
+
+func (d Decoder) respondWithError(w http.ResponseWriter, r *http.Request, err error) {
+	responder := d.ResponseProvider.Responder(w, r)
+	if problemErr, ok := err.(response.ProblemError); ok {
+		problem := problemErr.Problem()
+		responder.Respond(problem.Status, problem)
+		return
+	}
+	responder.RespondWithProblem(http.StatusInternalServerError, "Could not decode the request")
+}
+
+// Decode decodes the request into the struct that v points to, the body is decoded
+// as JSON, and fields with a path, query or header tag are set from the mux.Vars,
+// the query and the headers of the request. The fields are then validated with
+// the rules of their validate tag, which are the rules of config.Bind, for example:
+//
+//	ID    int    `path:"id" validate:"min=1"`
+//	Page  int    `query:"page"`
+//	Trace string `header:"X-Trace-ID"`
+//	Title string `json:"title" validate:"required,max=100"`
+//
+// When the request is not valid the error is a *response.Problem, with the
+// invalid-params of the request when its parameters are not valid.
+func Decode(r *http.Request, v interface{}) error {
+	return decode(r, v, DefaultMaxBodySize)
+}
+
+func decode(r *http.Request, v interface{}, maxBodySize int64) error {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot decode the request into (%T), it must be a pointer to a struct", v)
+	}
+	tagged := taggedFields(value.Elem())
+	values := make([]reflect.Value, len(tagged))
+	for i, field := range tagged {
+		values[i] = reflect.New(field.Type()).Elem()
+		values[i].Set(field)
+	}
+	if err := decodeBody(r, v, maxBodySize); err != nil {
+		return err
+	}
+	for i, field := range tagged {
+		field.Set(values[i])
+	}
+	params, err := decodeFields(r, value.Elem(), "")
+	if err != nil {
+		return err
+	}
+	if len(params) > 0 {
+		return response.NewInvalidParamsProblem(params)
+	}
+	return nil
+}
+
+// decodeBody decodes the JSON body of the request, if it has one
+func decodeBody(r *http.Request, v interface{}, maxBodySize int64) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodySize+1))
+	if err != nil {
+		return response.NewHTTPProblem(http.StatusBadRequest, "INVALID_BODY")
+	}
+	if int64(len(body)) > maxBodySize {
+		return response.NewHTTPProblem(http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE")
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+			return response.NewInvalidParamsProblem([]response.InvalidParam{{
+				Name:   typeErr.Field,
+				Reason: fmt.Sprintf("must be a %s", typeErr.Type),
+			}})
+		}
+		return response.NewHTTPProblem(http.StatusBadRequest, "INVALID_BODY")
+	}
+	return nil
+}
+
+// decodeFields sets the fields with a path, query or header tag, and validates
+// the fields of the struct and its nested structs
+func decodeFields(r *http.Request, value reflect.Value, prefix string) ([]response.InvalidParam, error) {
+	var params []response.InvalidParam
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		fieldValue := value.Field(i)
+		name, values, ok := fieldSource(r, field)
+		if ok && len(values) > 0 {
+			if err := setField(fieldValue, values); err != nil {
+				if _, unsupported := err.(unsupportedError); unsupported {
+					return nil, err
+				}
+				params = append(params, response.InvalidParam{Name: name, Reason: err.Error()})
+				continue
+			}
+		}
+		if !ok {
+			name = jsonName(field, prefix)
+			if name == "" {
+				continue
+			}
+		}
+		for _, reason := range config.CheckRules(fieldValue, field.Tag.Get("validate")) {
+			params = append(params, response.InvalidParam{Name: name, Reason: reason})
+		}
+		if !ok && fieldValue.Kind() == reflect.Struct && fieldValue.Type() != timeType {
+			nested, err := decodeFields(r, fieldValue, name)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, nested...)
+		}
+	}
+	return params, nil
+}
+
+// taggedFields gives the fields with a path, query or header tag, so that they
+// are not set from the body
+func taggedFields(value reflect.Value) []reflect.Value {
+	var fields []reflect.Value
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		if hasSource(field) {
+			fields = append(fields, value.Field(i))
+			continue
+		}
+		if value.Field(i).Kind() == reflect.Struct && value.Field(i).Type() != timeType {
+			fields = append(fields, taggedFields(value.Field(i))...)
+		}
+	}
+	return fields
+}
+
+// hasSource reports whether the field has a path, query or header tag
+func hasSource(field reflect.StructField) bool {
+	for _, tag := range []string{"path", "query", "header"} {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldSource finds the values of a field with a path, query or header tag
+func fieldSource(r *http.Request, field reflect.StructField) (string, []string, bool) {
+	if name, ok := field.Tag.Lookup("path"); ok {
+		if value, found := mux.Vars(r)[name]; found {
+			return name, []string{value}, true
+		}
+		return name, nil, true
+	}
+	if name, ok := field.Tag.Lookup("query"); ok {
+		return name, r.URL.Query()[name], true
+	}
+	if name, ok := field.Tag.Lookup("header"); ok {
+		return name, r.Header[http.CanonicalHeaderKey(name)], true
+	}
+	return "", nil, false
+}
+
+// jsonName gives the name of a field in the body, prefixed with the names of the
+// structs that it is nested in
+func jsonName(field reflect.StructField, prefix string) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		name = field.Name
+	}
+	if prefix != "" {
+		return fmt.Sprintf("%s.%s", prefix, name)
+	}
+	return name
+}
+
+// unsupportedError is returned when a field has a type that cannot be set from a
+// path, query or header value
+type unsupportedError struct {
+	fieldType reflect.Type
+}
+
+func (e unsupportedError) Error() string {
+	return fmt.Sprintf("cannot decode a request value into (%s)", e.fieldType)
+}
+
+// setField parses the values into the field, slices are set to every value,
+// other types to the first value
+func setField(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), 0, len(values))
+		for _, raw := range values {
+			element := reflect.New(field.Type().Elem()).Elem()
+			if err := setValue(element, raw); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, element)
+		}
+		field.Set(slice)
+		return nil
+	}
+	return setValue(field, values[0])
+}
+
+// nolint: gocyclo
+func setValue(field reflect.Value, raw string) error {
+	if field.Addr().Type().Implements(textUnmarshalerType) {
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw)); err != nil {
+			return errors.New("is not valid")
+		}
+		return nil
+	}
+	switch {
+	case field.Type() == durationType:
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			return errors.New("must be a duration")
+		}
+		field.SetInt(int64(parsed))
+	case field.Kind() == reflect.String:
+		field.SetString(raw)
+	case field.Kind() == reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be a boolean")
+		}
+		field.SetBool(parsed)
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		parsed, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be an integer")
+		}
+		field.SetInt(parsed)
+	case field.Kind() >= reflect.Uint && field.Kind() <= reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive integer")
+		}
+		field.SetUint(parsed)
+	case field.Kind() == reflect.Float32 || field.Kind() == reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(parsed)
+	case field.Kind() == reflect.Ptr:
+		pointer := reflect.New(field.Type().Elem())
+		if err := setValue(pointer.Elem(), raw); err != nil {
+			return err
+		}
+		field.Set(pointer)
+	default:
+		return unsupportedError{fieldType: field.Type()}
+	}
+	return nil
+}
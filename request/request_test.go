@@ -0,0 +1,154 @@
+package request_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BlackBX/service-framework/request"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type Address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type CreateTodo struct {
+	ID      int      `path:"id" validate:"min=1"`
+	Tags    []string `query:"tag"`
+	Page    int      `query:"page"`
+	Trace   string   `header:"X-Trace-ID"`
+	Title   string   `json:"title" validate:"required,max=10"`
+	Status  string   `json:"status,omitempty" validate:"oneof=open|done"`
+	Address Address  `json:"address"`
+}
+
+func newRequest(body, target string, vars map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	r.Header.Set("X-Trace-ID", "trace")
+	return mux.SetURLVars(r, vars)
+}
+
+func TestDecode(t *testing.T) {
+	r := newRequest(
+		`{"title": "Write", "status": "open", "address": {"city": "Leeds"}}`,
+		"/todos/3?tag=a&tag=b&page=2",
+		map[string]string{"id": "3"},
+	)
+	got := CreateTodo{}
+	if err := request.Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := CreateTodo{
+		ID:      3,
+		Tags:    []string{"a", "b"},
+		Page:    2,
+		Trace:   "trace",
+		Title:   "Write",
+		Status:  "open",
+		Address: Address{City: "Leeds"},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected (%+v), got (%+v)", expected, got)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	tests := map[string]struct {
+		request  *http.Request
+		expected *response.Problem
+	}{
+		"invalid params": {
+			request: newRequest(`{"title": "A very long title", "status": "late"}`, "/todos/x?page=two", map[string]string{"id": "0"}),
+			expected: response.NewInvalidParamsProblem([]response.InvalidParam{
+				{Name: "id", Reason: "must be at least 1"},
+				{Name: "page", Reason: "must be an integer"},
+				{Name: "title", Reason: "must be at most 10"},
+				{Name: "status", Reason: "must be one of (open, done)"},
+				{Name: "address.city", Reason: "is required"},
+			}),
+		},
+		"wrong type": {
+			request: newRequest(`{"title": 1}`, "/todos/1", map[string]string{"id": "1"}),
+			expected: response.NewInvalidParamsProblem([]response.InvalidParam{
+				{Name: "title", Reason: "must be a string"},
+			}),
+		},
+		"malformed body": {
+			request:  newRequest(`{"title"`, "/todos/1", map[string]string{"id": "1"}),
+			expected: response.NewHTTPProblem(http.StatusBadRequest, "INVALID_BODY"),
+		},
+		"large body": {
+			request:  newRequest(`{"title": "`+strings.Repeat("a", request.DefaultMaxBodySize)+`"}`, "/todos/1", nil),
+			expected: response.NewHTTPProblem(http.StatusRequestEntityTooLarge, "BODY_TOO_LARGE"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := request.Decode(test.request, &CreateTodo{})
+			if !reflect.DeepEqual(error(test.expected), err) {
+				t.Fatalf("expected the error (%+v), got (%+v)", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestDecodeIgnoresTaggedFieldsInBody(t *testing.T) {
+	r := newRequest(
+		`{"ID": 7, "Page": 9, "Trace": "body", "title": "Write", "status": "open", "address": {"city": "Leeds"}}`,
+		"/todos/3",
+		map[string]string{"id": "3"},
+	)
+	r.Header.Del("X-Trace-ID")
+	got := CreateTodo{}
+	if err := request.Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+	expected := CreateTodo{
+		ID:      3,
+		Title:   "Write",
+		Status:  "open",
+		Address: Address{City: "Leeds"},
+	}
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected (%+v), got (%+v)", expected, got)
+	}
+}
+
+func TestDecodeNotStruct(t *testing.T) {
+	value := 1
+	if err := request.Decode(newRequest("", "/", nil), &value); err == nil {
+		t.Fatal("expected an error decoding into an int, got none")
+	}
+}
+
+func TestDecoderDecodeOrRespond(t *testing.T) {
+	decoder := request.Decoder{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		MaxBodySize:      request.DefaultMaxBodySize,
+	}
+	rw := httptest.NewRecorder()
+	if decoder.DecodeOrRespond(rw, newRequest(`{}`, "/todos/1", map[string]string{"id": "1"}), &CreateTodo{}) {
+		t.Fatal("expected the request to be invalid")
+	}
+	if rw.Code != http.StatusBadRequest {
+		t.Fatalf("expected the status (%d), got (%d)", http.StatusBadRequest, rw.Code)
+	}
+	problem := map[string]interface{}{}
+	if err := json.NewDecoder(rw.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "title", "reason": "is required"},
+		map[string]interface{}{"name": "status", "reason": "must be one of (open, done)"},
+		map[string]interface{}{"name": "address.city", "reason": "is required"},
+	}
+	if !reflect.DeepEqual(expected, problem["invalid-params"]) {
+		t.Fatalf("expected the invalid-params (%+v), got (%+v)", expected, problem["invalid-params"])
+	}
+}
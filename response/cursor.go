@@ -0,0 +1,98 @@
+package response
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCursor is wrapped by the CursorErrors of cursors that are not valid
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorField is a field that a page of a collection is ordered by
+type CursorField struct {
+	Name       string `json:"name"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// Cursor is the position of a page of a collection, it holds the values of the
+// fields of the last row of the previous page, and the fields that it is in the
+// order of, so that it is not used with a different order
+type Cursor struct {
+	Fields []CursorField `json:"fields"`
+	Values []interface{} `json:"values"`
+}
+
+// Encode encodes the cursor as URL safe base64 of its JSON
+func (c Cursor) Encode() (string, error) {
+	if len(c.Fields) != len(c.Values) {
+		return "", fmt.Errorf("could not encode the cursor with (%d) fields and (%d) values", len(c.Fields), len(c.Values))
+	}
+	encoded, err := json.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("could not encode the cursor, got error (%w)", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+// DecodeCursor decodes the cursor, which must be in the order of the fields, the
+// numbers of its values are decoded as json.Numbers so that they are not rounded.
+// The values must be scalars, not objects or arrays. When the cursor is not valid
+// the error is a CursorError.
+func DecodeCursor(raw string, fields []CursorField) (Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return Cursor{}, CursorError{Cursor: raw, Reason: "is not a valid cursor"}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(decoded))
+	decoder.UseNumber()
+	cursor := Cursor{}
+	if err := decoder.Decode(&cursor); err != nil || len(cursor.Fields) != len(cursor.Values) {
+		return Cursor{}, CursorError{Cursor: raw, Reason: "is not a valid cursor"}
+	}
+	if !equalFields(fields, cursor.Fields) {
+		return Cursor{}, CursorError{Cursor: raw, Reason: "is not a cursor of the order of the page"}
+	}
+	for _, value := range cursor.Values {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return Cursor{}, CursorError{Cursor: raw, Reason: "is not a valid cursor"}
+		}
+	}
+	return cursor, nil
+}
+
+func equalFields(expected, got []CursorField) bool {
+	if len(expected) != len(got) {
+		return false
+	}
+	for i := range expected {
+		if expected[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CursorError is the error of a cursor that is not valid, its problem is a bad
+// request with the reason in the invalid-params of the cursor
+type CursorError struct {
+	Cursor string
+	Reason string
+}
+
+func (e CursorError) Error() string {
+	return fmt.Sprintf("the cursor (%s) %s, got error (%s)", e.Cursor, e.Reason, ErrInvalidCursor)
+}
+
+// Unwrap returns ErrInvalidCursor
+func (e CursorError) Unwrap() error {
+	return ErrInvalidCursor
+}
+
+// Problem returns the problem of the cursor
+func (e CursorError) Problem() *Problem {
+	return NewInvalidParamsProblem([]InvalidParam{{Name: "cursor", Reason: e.Reason}})
+}
@@ -0,0 +1,178 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// Encoder encodes values to the writer that it was created with
+type Encoder interface {
+	Encode(v interface{}) error
+}
+
+// Encoding describes how to encode responses with a content type, problems are
+// encoded with the ProblemContentType, and with the NewProblemEncoder when the
+// content type cannot encode problems. Streams are encoded with the
+// NewStreamEncoder when the values of the content type cannot be told apart
+// when they are written one after another.
+type Encoding struct {
+	ContentType        string
+	ProblemContentType string
+	NewEncoder         func(w io.Writer) Encoder
+	NewProblemEncoder  func(w io.Writer) Encoder
+	NewStreamEncoder   func(w io.Writer) Encoder
+}
+
+// Constructor creates the ResponderConstructor of an EncoderResponder for the encoding
+func (e Encoding) Constructor() ResponderConstructor {
+	return func(logger *zap.Logger, rw http.ResponseWriter, r *http.Request) Responder {
+		return EncoderResponder{
+			Encoding:       e,
+			logger:         logger,
+			responseWriter: rw,
+			request:        r,
+		}
+	}
+}
+
+// XMLEncoding encodes responses as XML, problems are application/problem+xml
+var XMLEncoding = Encoding{
+	ContentType:        "application/xml",
+	ProblemContentType: "application/problem+xml",
+	NewEncoder: func(w io.Writer) Encoder {
+		return xml.NewEncoder(w)
+	},
+}
+
+// MessagePackEncoding encodes responses as MessagePack, the fields of structs are
+// named as they are in JSON
+var MessagePackEncoding = Encoding{
+	ContentType:        "application/msgpack",
+	ProblemContentType: "application/msgpack",
+	NewEncoder: func(w io.Writer) Encoder {
+		return MessagePackEncoder{Writer: w}
+	},
+}
+
+// ProtobufEncoding encodes responses that are proto.Messages as protobuf, problems
+// are encoded as application/problem+json. The messages of streams are delimited
+// by their size as a varint, as they are by protodelim.
+var ProtobufEncoding = Encoding{
+	ContentType:        "application/x-protobuf",
+	ProblemContentType: "application/problem+json",
+	NewEncoder: func(w io.Writer) Encoder {
+		return ProtobufEncoder{Writer: w}
+	},
+	NewProblemEncoder: func(w io.Writer) Encoder {
+		return json.NewEncoder(w)
+	},
+	NewStreamEncoder: func(w io.Writer) Encoder {
+		return ProtobufEncoder{Writer: w, Delimited: true}
+	},
+}
+
+// ProtobufEncoder encodes proto.Messages to the Writer, when it is Delimited each
+// message is preceded by its size as a varint
+type ProtobufEncoder struct {
+	Writer    io.Writer
+	Delimited bool
+}
+
+// Encode encodes the value, which must be a proto.Message
+func (e ProtobufEncoder) Encode(v interface{}) error {
+	message, ok := v.(proto.Message)
+	if !ok {
+		return fmt.Errorf("cannot encode (%T) as protobuf, it is not a proto.Message", v)
+	}
+	encoded, err := proto.Marshal(message)
+	if err != nil {
+		return err
+	}
+	if e.Delimited {
+		encoded = append(protowire.AppendVarint(nil, uint64(len(encoded))), encoded...)
+	}
+	_, err = e.Writer.Write(encoded)
+	return err
+}
+
+// EncoderResponder is a responder that responds with the content type of its
+// Encoding, each value is encoded before the status is written so that a value
+// that cannot be encoded is responded to with a problem
+type EncoderResponder struct {
+	Encoding       Encoding
+	logger         *zap.Logger
+	responseWriter http.ResponseWriter
+	request        *http.Request
+}
+
+// RespondWithProblem will respond with the given status code and detail, with
+// an API problem
+func (r EncoderResponder) RespondWithProblem(statusCode int, detail string) {
+	r.respondWithProblem(NewHTTPProblem(statusCode, detail))
+}
+
+func (r EncoderResponder) respondWithProblem(problem *Problem) {
+	newEncoder := r.Encoding.NewProblemEncoder
+	if newEncoder == nil {
+		newEncoder = r.Encoding.NewEncoder
+	}
+	buffer := &bytes.Buffer{}
+	if err := newEncoder(buffer).Encode(problem); err != nil {
+		r.logger.Error("Could not respond with problem", zap.Any("value", problem), zap.Error(err))
+	}
+	r.write(problem.Status, r.Encoding.ProblemContentType, buffer.Bytes())
+}
+
+// Respond will take a given value and respond with it as the body, the links of
+// a Linker are added to the Link header
+func (r EncoderResponder) Respond(statusCode int, value interface{}) {
+	if problem, ok := value.(*Problem); ok {
+		r.respondWithProblem(problem)
+		return
+	}
+	buffer := &bytes.Buffer{}
+	if err := r.Encoding.NewEncoder(buffer).Encode(value); err != nil {
+		r.logger.Error("Could not respond with value", zap.Any("value", value), zap.Error(err))
+		r.RespondWithProblem(http.StatusInternalServerError, "Could not encode the response")
+		return
+	}
+	setLinkHeader(r.responseWriter.Header(), r.request, value)
+	r.write(statusCode, r.Encoding.ContentType, buffer.Bytes())
+}
+
+// RespondStream will stream a response of values to the client, each value is
+// flushed to the client as it is written
+func (r EncoderResponder) RespondStream(statusCode int, valueStream <-chan interface{}) {
+	r.responseWriter.Header().Set("Content-Type", r.Encoding.ContentType)
+	r.responseWriter.WriteHeader(statusCode)
+	newEncoder := r.Encoding.NewStreamEncoder
+	if newEncoder == nil {
+		newEncoder = r.Encoding.NewEncoder
+	}
+	encoder := newEncoder(r.responseWriter)
+	flusher, canFlush := r.responseWriter.(http.Flusher)
+	for value := range valueStream {
+		if err := encoder.Encode(value); err != nil {
+			r.logger.Error("Could not respond with value stream", zap.Any("value", value), zap.Error(err))
+		}
+		if canFlush {
+			flusher.Flush()
+		}
+	}
+}
+
+func (r EncoderResponder) write(statusCode int, contentType string, body []byte) {
+	r.responseWriter.Header().Set("Content-Type", contentType)
+	r.responseWriter.WriteHeader(statusCode)
+	if _, err := r.responseWriter.Write(body); err != nil {
+		r.logger.Error("Could not write the response", zap.Error(err))
+	}
+}
@@ -0,0 +1,169 @@
+package response
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// HandlerFunc is a handler that returns an error instead of responding with a
+// problem, it is adapted to an http.Handler by an ErrorHandler
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ErrorMapping maps an error, and the errors that wrap it, to a problem with the
+// status and detail, or to a problem of the Type when it is set
+type ErrorMapping struct {
+	Err    error
+	Status int
+	Detail string
+	Type   *ProblemType
+}
+
+// DefaultErrorMappings are the errors that are mapped to problems by default
+var DefaultErrorMappings = []ErrorMapping{
+	{Err: sql.ErrNoRows, Status: http.StatusNotFound, Detail: "NOT_FOUND"},
+	{Err: context.DeadlineExceeded, Status: http.StatusGatewayTimeout, Detail: "TIMEOUT"},
+}
+
+// ErrorMapper maps errors to problems
+type ErrorMapper struct {
+	Mappings []ErrorMapping
+}
+
+// Problem maps the error to a problem, errors that are ProblemErrors are their
+// own problem, otherwise the first mapping of the error is used. It returns false
+// when the error is not mapped.
+func (m ErrorMapper) Problem(err error) (*Problem, bool) {
+	var problemErr ProblemError
+	if errors.As(err, &problemErr) {
+		problem := *problemErr.Problem()
+		return &problem, true
+	}
+	for _, mapping := range m.Mappings {
+		if !errors.Is(err, mapping.Err) {
+			continue
+		}
+		if mapping.Type != nil {
+			return mapping.Type.New(mapping.Detail), true
+		}
+		return NewHTTPProblem(mapping.Status, mapping.Detail), true
+	}
+	return nil, false
+}
+
+// ErrorHandler adapts HandlerFuncs to http.Handlers, which respond with the
+// problem of the error that the HandlerFunc returns. Errors that are not mapped
+// are internal errors, their detail is hidden from the client unless
+// ExposeInternalErrors is set. Internal errors are logged.
+type ErrorHandler struct {
+	ResponseProvider     ResponderProvider
+	Mapper               ErrorMapper
+	Logger               *zap.Logger
+	ExposeInternalErrors bool
+}
+
+// ErrorHandlerParams are the dependencies of the ErrorHandler, the ErrorMappings
+// in the "error-mappings" group are used before the DefaultErrorMappings
+type ErrorHandlerParams struct {
+	fx.In
+
+	ResponseProvider ResponderProvider
+	Logger           *zap.Logger
+	Config           dependency.ConfigGetter
+	Mappings         []ErrorMapping `group:"error-mappings"`
+}
+
+// NewErrorHandler creates a new instance of the ErrorHandler, the details of
+// internal errors are only exposed when response-expose-internal-errors is set
+func NewErrorHandler(params ErrorHandlerParams) ErrorHandler {
+	mappings := append([]ErrorMapping{}, params.Mappings...)
+	return ErrorHandler{
+		ResponseProvider:     params.ResponseProvider,
+		Mapper:               ErrorMapper{Mappings: append(mappings, DefaultErrorMappings...)},
+		Logger:               params.Logger,
+		ExposeInternalErrors: params.Config.GetBool("response-expose-internal-errors"),
+	}
+}
+
+// Handle adapts the HandlerFunc to an http.Handler, the instance of the problem
+// is the ID of the request when the problem does not have an instance, and its
+// status is 500 when it does not have a status
+func (h ErrorHandler) Handle(handler HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		writer := &trackingWriter{ResponseWriter: rw}
+		err := handler(writer, r)
+		if err == nil {
+			return
+		}
+		logger := h.Logger.With(zap.String("request-id", RequestID(r)), zap.Error(err))
+		if writer.written {
+			logger.Error("Handler returned an error after responding")
+			return
+		}
+		problem, ok := h.Mapper.Problem(err)
+		if !ok {
+			logger.Error("Handler returned an internal error")
+			detail := "INTERNAL_ERROR"
+			if h.ExposeInternalErrors {
+				detail = err.Error()
+			}
+			problem = NewHTTPProblem(http.StatusInternalServerError, detail)
+		}
+		if problem.Status == 0 {
+			problem.Status = http.StatusInternalServerError
+		}
+		if problem.Instance == "" {
+			problem.Instance = RequestID(r)
+		}
+		h.ResponseProvider.Responder(rw, r).Respond(problem.Status, problem)
+	})
+}
+
+// trackingWriter records whether the response has been written, it forwards the
+// http.Flusher, http.Hijacker and http.Pusher of the http.ResponseWriter
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *trackingWriter) WriteHeader(statusCode int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *trackingWriter) Write(body []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(body)
+}
+
+func (w *trackingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.written = true
+		flusher.Flush()
+	}
+}
+
+func (w *trackingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer (%T) cannot be hijacked", w.ResponseWriter)
+	}
+	w.written = true
+	return hijacker.Hijack()
+}
+
+func (w *trackingWriter) Push(target string, opts *http.PushOptions) error {
+	pusher, ok := w.ResponseWriter.(http.Pusher)
+	if !ok {
+		return http.ErrNotSupported
+	}
+	return pusher.Push(target, opts)
+}
@@ -0,0 +1,186 @@
+package response_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BlackBX/service-framework/response"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var (
+	errConflict = errors.New("conflict")
+	errGone     = errors.New("gone")
+	goneType    = response.ProblemType{Type: "https://example.com/problems/gone", Title: "Todo Gone", Status: http.StatusGone}
+)
+
+func TestErrorHandler(t *testing.T) {
+	tests := map[string]struct {
+		err      error
+		expose   bool
+		expected *response.Problem
+		logged   int
+	}{
+		"problem": {
+			err:      response.NewHTTPProblem(http.StatusBadRequest, "BAD"),
+			expected: &response.Problem{Status: http.StatusBadRequest, Detail: "BAD", Instance: "request-1"},
+		},
+		"wrapped sentinel": {
+			err:      fmt.Errorf("could not find the todo, got error (%w)", sql.ErrNoRows),
+			expected: &response.Problem{Status: http.StatusNotFound, Detail: "NOT_FOUND", Instance: "request-1"},
+		},
+		"deadline": {
+			err:      context.DeadlineExceeded,
+			expected: &response.Problem{Status: http.StatusGatewayTimeout, Detail: "TIMEOUT", Instance: "request-1"},
+		},
+		"registered": {
+			err:      errConflict,
+			expected: &response.Problem{Status: http.StatusConflict, Detail: "CONFLICT", Instance: "request-1"},
+		},
+		"registered type": {
+			err:      errGone,
+			expected: &response.Problem{Status: http.StatusGone, Type: goneType.Type, Title: "Todo Gone", Detail: "GONE", Instance: "request-1"},
+		},
+		"internal": {
+			err:      errors.New("connection refused"),
+			expected: &response.Problem{Status: http.StatusInternalServerError, Detail: "INTERNAL_ERROR", Instance: "request-1"},
+			logged:   1,
+		},
+		"problem without status": {
+			err:      &response.Problem{Detail: "NO_STATUS"},
+			expected: &response.Problem{Status: http.StatusInternalServerError, Detail: "NO_STATUS", Instance: "request-1"},
+		},
+		"exposed internal": {
+			err:      errors.New("connection refused"),
+			expose:   true,
+			expected: &response.Problem{Status: http.StatusInternalServerError, Detail: "connection refused", Instance: "request-1"},
+			logged:   1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			handler := response.ErrorHandler{
+				ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+				Mapper: response.ErrorMapper{Mappings: append([]response.ErrorMapping{
+					{Err: errConflict, Status: http.StatusConflict, Detail: "CONFLICT"},
+					{Err: errGone, Detail: "GONE", Type: &goneType},
+				}, response.DefaultErrorMappings...)},
+				Logger:               zap.New(core),
+				ExposeInternalErrors: test.expose,
+			}
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(response.RequestIDHeader, "request-1")
+			response.RequestIDMiddleware(handler.Handle(func(w http.ResponseWriter, r *http.Request) error {
+				return test.err
+			})).ServeHTTP(rw, r)
+			if rw.Code != test.expected.Status {
+				t.Fatalf("expected the status (%d), got (%d)", test.expected.Status, rw.Code)
+			}
+			got := &response.Problem{}
+			if err := json.NewDecoder(rw.Body).Decode(got); err != nil {
+				t.Fatal(err)
+			}
+			if test.expected.Type == "" {
+				got.Type, got.Title = "", ""
+			}
+			if !reflect.DeepEqual(test.expected, got) {
+				t.Errorf("expected the problem (%+v), got (%+v)", test.expected, got)
+			}
+			if logs.Len() != test.logged {
+				t.Errorf("expected (%d) logs, got (%d)", test.logged, logs.Len())
+			}
+		})
+	}
+}
+
+func TestErrorHandlerAfterResponding(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	handler := response.ErrorHandler{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Logger:           zap.New(core),
+	}
+	rw := httptest.NewRecorder()
+	handler.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		w.WriteHeader(http.StatusAccepted)
+		return errors.New("failed after responding")
+	}).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rw.Code != http.StatusAccepted || rw.Body.Len() != 0 {
+		t.Fatalf("expected the response not to be changed, got (%d) (%s)", rw.Code, rw.Body.String())
+	}
+	if logs.FilterMessage("Handler returned an error after responding").Len() != 1 {
+		t.Fatal("expected the error to be logged")
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var id string
+	rw := httptest.NewRecorder()
+	response.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id = response.RequestID(r)
+	})).ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	if len(id) != 32 || rw.Header().Get(response.RequestIDHeader) != id {
+		t.Fatalf("expected a generated request ID in the response, got (%s) (%s)", id, rw.Header().Get(response.RequestIDHeader))
+	}
+}
+
+func TestErrorHandlerHijack(t *testing.T) {
+	handler := response.ErrorHandler{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Logger:           zap.NewNop(),
+	}
+	server := httptest.NewServer(handler.Handle(func(w http.ResponseWriter, r *http.Request) error {
+		if _, ok := w.(http.Flusher); !ok {
+			return errors.New("expected the writer to be an http.Flusher")
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, _ = rw.WriteString("HTTP/1.1 418 I'm a teapot\r\nContent-Length: 0\r\n\r\n")
+		_ = rw.Flush()
+		return errors.New("failed after hijacking")
+	}))
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusTeapot {
+		t.Fatalf("expected the hijacked connection to respond with (%d), got (%d)", http.StatusTeapot, res.StatusCode)
+	}
+}
+
+func TestRequestIDMiddlewareRejectsInvalidIDs(t *testing.T) {
+	tests := map[string]string{
+		"too long":    strings.Repeat("a", 129),
+		"line breaks": "request\nid",
+		"spaces":      "request id",
+	}
+	for name, header := range tests {
+		t.Run(name, func(t *testing.T) {
+			var id string
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set(response.RequestIDHeader, header)
+			response.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = response.RequestID(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if len(id) != 32 {
+				t.Fatalf("expected a generated request ID, got (%s)", id)
+			}
+		})
+	}
+}
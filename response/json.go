@@ -3,6 +3,7 @@ package response
 import (
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 )
@@ -22,46 +23,47 @@ func NewJSONResponder(logger *zap.Logger, rw http.ResponseWriter, r *http.Reques
 		responseWriter: rw,
 		request:        r,
 		Encoder:        encoder,
+		Heartbeat:      DefaultHeartbeat,
 	}
 }
 
-// JSONResponder is a responder that will respond with JSON responses
+// JSONResponder is a responder that will respond with JSON responses, streams
+// of Server-Sent Events send a comment every Heartbeat to keep the connection
+// open, a Heartbeat of 0 disables them
 type JSONResponder struct {
 	logger         *zap.Logger
 	responseWriter http.ResponseWriter
 	request        *http.Request
 	Encoder        JSONEncoder
+	Heartbeat      time.Duration
 }
 
 // RespondWithProblem will respond with the given status code and
 // detail, with an API problem
 func (r JSONResponder) RespondWithProblem(statusCode int, detail string) {
 	problem := NewHTTPProblem(statusCode, detail)
+	r.responseWriter.Header().Set("Content-Type", "application/problem+json")
 	r.responseWriter.WriteHeader(statusCode)
-	r.request.Header.Set("Content-Type", "application/problem+json")
 	if err := r.Encoder.Encode(problem); err != nil {
 		r.logger.Error("Could not respond with problem", zap.Any("value", problem))
 	}
 }
 
-// Respond will take a given struct and respond with it as the body
+// Respond will take a given struct and respond with it as the body, the links of
+// a Linker are added to the Link header
 func (r JSONResponder) Respond(statusCode int, value interface{}) {
+	r.responseWriter.Header().Set("Content-Type", contentTypeForValue(value))
+	setLinkHeader(r.responseWriter.Header(), r.request, value)
 	r.responseWriter.WriteHeader(statusCode)
-	r.request.Header.Set("Content-Type", contentTypeForValue(value))
 	if err := r.Encoder.Encode(value); err != nil {
 		r.logger.Error("Could not respond with value", zap.Any("value", value))
 	}
 }
 
-// RespondStream will stream a response of JSON values to the client
+// RespondStream will stream a response of JSON values to the client, in the mode
+// accepted by the request, see StreamModeFor
 func (r JSONResponder) RespondStream(statusCode int, valueStream <-chan interface{}) {
-	r.responseWriter.WriteHeader(statusCode)
-	r.request.Header.Set("Content-Type", "application/json")
-	for value := range valueStream {
-		if err := r.Encoder.Encode(value); err != nil {
-			r.logger.Error("Could not respond with value stream", zap.Any("value", value))
-		}
-	}
+	r.RespondStreamMode(statusCode, StreamModeFor(r.request), valueStream)
 }
 
 func contentTypeForValue(value interface{}) string {
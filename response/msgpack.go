@@ -0,0 +1,45 @@
+package response
+
+import (
+	"io"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessagePackEncoder encodes values as MessagePack to the Writer, the fields of
+// structs are named by their json tags, and maps are encoded in order of their keys
+type MessagePackEncoder struct {
+	Writer io.Writer
+}
+
+// Encode encodes the value as MessagePack
+func (e MessagePackEncoder) Encode(v interface{}) error {
+	encoder := msgpack.NewEncoder(e.Writer)
+	encoder.SetCustomStructTag("json")
+	encoder.SetSortMapKeys(true)
+	encoder.UseCompactInts(true)
+	return encoder.Encode(v)
+}
+
+// EncodeMsgpack encodes the problem as a map with its extension members inline,
+// as it is encoded in JSON
+func (p Problem) EncodeMsgpack(encoder *msgpack.Encoder) error {
+	members := map[string]interface{}{
+		"status": p.Status,
+		"type":   p.Type,
+		"title":  p.Title,
+		"detail": p.Detail,
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	if len(p.InvalidParams) > 0 {
+		members["invalid-params"] = p.InvalidParams
+	}
+	for name, value := range p.Extensions {
+		if !problemMemberNames[name] {
+			members[name] = value
+		}
+	}
+	return encoder.Encode(members)
+}
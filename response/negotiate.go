@@ -0,0 +1,138 @@
+package response
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+// ResponderRegistration registers the constructor of the Responder for a content
+// type, registrations are provided to the application in the "responders" group.
+// When a wildcard, such as application/*, accepts more than one registration, the
+// registration with the highest Priority is used, then the first by content type.
+type ResponderRegistration struct {
+	ContentType string
+	Constructor ResponderConstructor
+	Priority    int
+}
+
+// FactoryParams are the dependencies of a ResponderFactory that negotiates the
+// content type of the response
+type FactoryParams struct {
+	fx.In
+
+	Logger           *zap.Logger
+	DefaultResponder ResponderConstructor
+	Registrations    []ResponderRegistration `group:"responders"`
+}
+
+// NewNegotiatingFactory creates a new instance of the ResponderFactory that chooses
+// the Responder from the registrations based on the Accept header of the request,
+// the registrations are ordered by their Priority, then by their content type, so
+// that the order of the group does not change the Responder that is chosen
+func NewNegotiatingFactory(params FactoryParams) ResponderFactory {
+	registrations := append([]ResponderRegistration{}, params.Registrations...)
+	sort.SliceStable(registrations, func(i, j int) bool {
+		if registrations[i].Priority != registrations[j].Priority {
+			return registrations[i].Priority > registrations[j].Priority
+		}
+		return registrations[i].ContentType < registrations[j].ContentType
+	})
+	factory := NewFactory(params.Logger, params.DefaultResponder)
+	factory.Registrations = registrations
+	return factory
+}
+
+// acceptedMediaType is a media type of the Accept header, with its quality
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses the Accept header into the media types in order of preference,
+// media types with a quality of 0 are not acceptable and are left out
+func parseAccept(accept string) []acceptedMediaType {
+	var mediaTypes []acceptedMediaType
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := acceptedMediaType{
+			mediaType: strings.ToLower(strings.TrimSpace(params[0])),
+			quality:   1,
+		}
+		for _, param := range params[1:] {
+			keyValue := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(keyValue) == 2 && strings.TrimSpace(keyValue[0]) == "q" {
+				if quality, err := strconv.ParseFloat(strings.TrimSpace(keyValue[1]), 64); err == nil {
+					mediaType.quality = quality
+				}
+			}
+		}
+		if mediaType.mediaType != "" && mediaType.quality > 0 {
+			mediaTypes = append(mediaTypes, mediaType)
+		}
+	}
+	sort.SliceStable(mediaTypes, func(i, j int) bool {
+		return mediaTypes[i].quality > mediaTypes[j].quality
+	})
+	return mediaTypes
+}
+
+// matches reports whether the accepted media type accepts the content type, the
+// media type accepts its structured syntax suffix, so application/vnd.api+json
+// accepts application/json
+func (a acceptedMediaType) matches(contentType string) bool {
+	switch {
+	case a.mediaType == contentType:
+		return true
+	case strings.HasSuffix(a.mediaType, "/*"):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(a.mediaType, "*"))
+	}
+	slash, plus := strings.Index(a.mediaType, "/"), strings.LastIndex(a.mediaType, "+")
+	return slash != -1 && plus > slash && a.mediaType[:slash+1]+a.mediaType[plus+1:] == contentType
+}
+
+// negotiate chooses the constructor of the Responder for the Accept header, it
+// returns the default constructor when any content type is accepted, and false
+// when none of the registered content types are accepted
+func (rf ResponderFactory) negotiate(accept string) (ResponderConstructor, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return rf.DefaultResponder, true
+	}
+	for _, mediaType := range parseAccept(accept) {
+		if mediaType.mediaType == "*/*" {
+			return rf.DefaultResponder, true
+		}
+		for _, registration := range rf.Registrations {
+			if mediaType.matches(registration.ContentType) {
+				return registration.Constructor, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// NotAcceptableResponder responds with a 406 problem whatever the response, it is
+// used when none of the content types accepted by the request can be produced
+type NotAcceptableResponder struct {
+	Responder Responder
+}
+
+// RespondWithProblem responds with the 406 problem
+func (r NotAcceptableResponder) RespondWithProblem(int, string) {
+	r.Responder.RespondWithProblem(http.StatusNotAcceptable, "NOT_ACCEPTABLE")
+}
+
+// Respond responds with the 406 problem
+func (r NotAcceptableResponder) Respond(int, interface{}) {
+	r.RespondWithProblem(http.StatusNotAcceptable, "")
+}
+
+// RespondStream responds with the 406 problem, and drains the stream
+func (r NotAcceptableResponder) RespondStream(_ int, valueStream <-chan interface{}) {
+	r.RespondWithProblem(http.StatusNotAcceptable, "")
+	drain(valueStream)
+}
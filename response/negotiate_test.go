@@ -0,0 +1,175 @@
+package response_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type Greeting struct {
+	Message string `json:"message" xml:"message"`
+	Count   int    `json:"count" xml:"count"`
+}
+
+func negotiatingFactory() response.ResponderFactory {
+	return response.NewNegotiatingFactory(response.FactoryParams{
+		Logger:           zap.NewNop(),
+		DefaultResponder: response.NewJSONResponder,
+		Registrations: []response.ResponderRegistration{
+			{ContentType: "application/msgpack", Constructor: response.MessagePackEncoding.Constructor()},
+			{ContentType: "application/xml", Constructor: response.XMLEncoding.Constructor()},
+			{ContentType: "application/json", Constructor: response.NewJSONResponder, Priority: 1},
+			{ContentType: "application/x-protobuf", Constructor: response.ProtobufEncoding.Constructor()},
+		},
+	})
+}
+
+func respond(accept string, value interface{}) *httptest.ResponseRecorder {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", accept)
+	negotiatingFactory().Responder(rw, r).Respond(http.StatusOK, value)
+	return rw
+}
+
+func TestResponderFactoryNegotiates(t *testing.T) {
+	greeting := Greeting{Message: "Hello", Count: 1}
+	tests := map[string]struct {
+		accept string
+		status int
+		body   string
+	}{
+		"no accept":      {accept: "", status: http.StatusOK, body: `{"message":"Hello","count":1}` + "\n"},
+		"any":            {accept: "text/html, */*;q=0.8", status: http.StatusOK, body: `{"message":"Hello","count":1}` + "\n"},
+		"vendor json":    {accept: "application/vnd.example.v2+json", status: http.StatusOK, body: `{"message":"Hello","count":1}` + "\n"},
+		"xml":            {accept: "application/xml", status: http.StatusOK, body: `<Greeting><message>Hello</message><count>1</count></Greeting>`},
+		"quality":        {accept: "application/json;q=0.5, application/xml", status: http.StatusOK, body: `<Greeting><message>Hello</message><count>1</count></Greeting>`},
+		"wildcard":       {accept: "application/*", status: http.StatusOK, body: `{"message":"Hello","count":1}` + "\n"},
+		"msgpack":        {accept: "application/msgpack", status: http.StatusOK, body: "\x82\xa7message\xa5Hello\xa5count\x01"},
+		"not acceptable": {accept: "text/html, application/json;q=0", status: http.StatusNotAcceptable},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := respond(test.accept, greeting)
+			if rw.Code != test.status {
+				t.Fatalf("expected the status (%d), got (%d)", test.status, rw.Code)
+			}
+			if rw.Header().Get("Vary") != "Accept" {
+				t.Errorf("expected the response to vary by Accept, got (%s)", rw.Header().Get("Vary"))
+			}
+			if test.body != "" && rw.Body.String() != test.body {
+				t.Errorf("expected the body (%q), got (%q)", test.body, rw.Body.String())
+			}
+		})
+	}
+}
+
+func TestResponderFactoryNotAcceptableProblem(t *testing.T) {
+	rw := respond("text/html", Greeting{})
+	if !strings.Contains(rw.Body.String(), `"detail":"NOT_ACCEPTABLE"`) {
+		t.Fatalf("expected a not acceptable problem, got (%s)", rw.Body.String())
+	}
+}
+
+func TestEncoderResponderProtobuf(t *testing.T) {
+	message := &wrapperspb.StringValue{Value: "Hello"}
+	rw := respond("application/x-protobuf", message)
+	expected, err := proto.Marshal(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rw.Header().Get("Content-Type") != "application/x-protobuf" || !bytes.Equal(expected, rw.Body.Bytes()) {
+		t.Fatalf("expected the protobuf message, got (%s) (%q)", rw.Header().Get("Content-Type"), rw.Body.String())
+	}
+	rw = respond("application/x-protobuf", Greeting{})
+	if rw.Code != http.StatusInternalServerError || rw.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("expected a JSON problem for a value that is not a message, got (%d) (%s)", rw.Code, rw.Header().Get("Content-Type"))
+	}
+}
+
+func TestEncoderResponderXMLProblem(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/xml")
+	negotiatingFactory().Responder(rw, r).RespondWithProblem(http.StatusNotFound, "ROUTE_NOT_FOUND")
+	expected := `<problem xmlns="urn:ietf:rfc:7807"><status>404</status><type>https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html</type><title>Not Found</title><detail>ROUTE_NOT_FOUND</detail></problem>`
+	if rw.Header().Get("Content-Type") != "application/problem+xml" || rw.Body.String() != expected {
+		t.Fatalf("expected the XML problem (%s), got (%s) (%s)", expected, rw.Header().Get("Content-Type"), rw.Body.String())
+	}
+}
+
+func TestMessagePackEncoderProblem(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	problem := response.NewHTTPProblem(http.StatusBadRequest, "BAD").WithExtension("trace-id", "abc")
+	if err := (response.MessagePackEncoder{Writer: buffer}).Encode(problem); err != nil {
+		t.Fatal(err)
+	}
+	decoded := map[string]interface{}{}
+	if err := msgpack.Unmarshal(buffer.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["detail"] != "BAD" || decoded["trace-id"] != "abc" {
+		t.Fatalf("expected the problem with its extension members, got (%+v)", decoded)
+	}
+}
+
+func TestEncoderResponderProtobufStream(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/x-protobuf")
+	stream := make(chan interface{}, 2)
+	stream <- &wrapperspb.StringValue{Value: "Hello"}
+	stream <- &wrapperspb.StringValue{Value: "World"}
+	close(stream)
+	negotiatingFactory().Responder(rw, r).RespondStream(http.StatusOK, stream)
+	body := rw.Body.Bytes()
+	for _, expected := range []string{"Hello", "World"} {
+		length, n := protowire.ConsumeVarint(body)
+		if n < 0 || uint64(len(body)-n) < length {
+			t.Fatalf("expected a varint delimited message, got (%q)", body)
+		}
+		message := &wrapperspb.StringValue{}
+		if err := proto.Unmarshal(body[n:n+int(length)], message); err != nil {
+			t.Fatal(err)
+		}
+		if message.Value != expected {
+			t.Fatalf("expected the message (%s), got (%s)", expected, message.Value)
+		}
+		body = body[n+int(length):]
+	}
+	if len(body) != 0 {
+		t.Fatalf("expected only two messages, got (%q) left over", body)
+	}
+}
+
+func TestResponderFactoryVaryOnce(t *testing.T) {
+	rw := httptest.NewRecorder()
+	rw.Header().Set("Vary", "Origin, accept")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	negotiatingFactory().Responder(rw, r)
+	negotiatingFactory().Responder(rw, r).Respond(http.StatusOK, Greeting{})
+	if vary := rw.Header()["Vary"]; len(vary) != 1 || vary[0] != "Origin, accept" {
+		t.Fatalf("expected the response to vary by Accept once, got (%q)", vary)
+	}
+}
+
+func TestEncoderResponderXMLProblemExtensions(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/xml")
+	problem := response.NewHTTPProblem(http.StatusNotFound, "ROUTE_NOT_FOUND").WithExtension("trace-id", "abc").WithExtension("detail", "ignored")
+	negotiatingFactory().Responder(rw, r).Respond(problem.Status, problem)
+	expected := `<problem xmlns="urn:ietf:rfc:7807"><status>404</status><type>https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html</type><title>Not Found</title><detail>ROUTE_NOT_FOUND</detail><trace-id>abc</trace-id></problem>`
+	if rw.Body.String() != expected {
+		t.Fatalf("expected the XML problem (%s), got (%s)", expected, rw.Body.String())
+	}
+}
@@ -0,0 +1,92 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Page is the envelope of a page of a collection, a page is paginated by cursor
+// when it has a NextCursor or a PrevCursor, otherwise it is paginated by offset.
+// Responders add the Link header of the page to the response.
+type Page struct {
+	Items      interface{} `json:"items" xml:"items"`
+	Limit      int         `json:"limit" xml:"limit"`
+	Offset     int         `json:"offset,omitempty" xml:"offset,omitempty"`
+	Total      *int64      `json:"total,omitempty" xml:"total,omitempty"`
+	HasMore    bool        `json:"has-more" xml:"has-more"`
+	NextCursor string      `json:"next-cursor,omitempty" xml:"next-cursor,omitempty"`
+	PrevCursor string      `json:"prev-cursor,omitempty" xml:"prev-cursor,omitempty"`
+}
+
+// Link is a link to a resource that is related to the response, see RFC 8288
+type Link struct {
+	URL string
+	Rel string
+}
+
+// String formats the link as a value of the Link header
+func (l Link) String() string {
+	return fmt.Sprintf("<%s>; rel=%q", l.URL, l.Rel)
+}
+
+// Linker is a response value that has links to related resources, which are
+// added to the Link header of the response
+type Linker interface {
+	Links(requestURL *url.URL) []Link
+}
+
+// Links returns the links to the first, previous and next pages of the page, they
+// are the URL of the request with the cursor or offset of the page
+func (p Page) Links(requestURL *url.URL) []Link {
+	links := []Link{{URL: pageURL(requestURL, "", "").String(), Rel: "first"}}
+	switch {
+	case p.PrevCursor != "":
+		links = append(links, Link{URL: pageURL(requestURL, "cursor", p.PrevCursor).String(), Rel: "prev"})
+	case p.Offset > 0:
+		offset := p.Offset - p.Limit
+		if offset < 0 {
+			offset = 0
+		}
+		links = append(links, Link{URL: pageURL(requestURL, "offset", strconv.Itoa(offset)).String(), Rel: "prev"})
+	}
+	switch {
+	case p.NextCursor != "":
+		links = append(links, Link{URL: pageURL(requestURL, "cursor", p.NextCursor).String(), Rel: "next"})
+	case p.HasMore && p.Limit > 0:
+		links = append(links, Link{URL: pageURL(requestURL, "offset", strconv.Itoa(p.Offset+p.Limit)).String(), Rel: "next"})
+	}
+	return links
+}
+
+// pageURL returns the URL of the request with the cursor and offset replaced by
+// the parameter, the URL is relative to the host of the request
+func pageURL(requestURL *url.URL, param string, value string) *url.URL {
+	query := requestURL.Query()
+	query.Del("cursor")
+	query.Del("offset")
+	if param != "" {
+		query.Set(param, value)
+	}
+	return &url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+}
+
+// setLinkHeader adds the links of the value to the Link header of the response,
+// when the value is a Linker
+func setLinkHeader(header http.Header, r *http.Request, value interface{}) {
+	linker, ok := value.(Linker)
+	if !ok || r == nil {
+		return
+	}
+	links := linker.Links(r.URL)
+	if len(links) == 0 {
+		return
+	}
+	values := make([]string, 0, len(links))
+	for _, link := range links {
+		values = append(values, link.String())
+	}
+	header.Set("Link", strings.Join(values, ", "))
+}
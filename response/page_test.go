@@ -0,0 +1,65 @@
+package response_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/BlackBX/service-framework/response"
+	"go.uber.org/zap"
+)
+
+func TestPageLinks(t *testing.T) {
+	requestURL, _ := url.Parse("/todos?limit=10&status=open&cursor=b2xk")
+	tests := map[string]struct {
+		page     response.Page
+		expected []response.Link
+	}{
+		"cursor": {
+			page: response.Page{Limit: 10, HasMore: true, NextCursor: "bmV4dA", PrevCursor: "cHJldg"},
+			expected: []response.Link{
+				{URL: "/todos?limit=10&status=open", Rel: "first"},
+				{URL: "/todos?cursor=cHJldg&limit=10&status=open", Rel: "prev"},
+				{URL: "/todos?cursor=bmV4dA&limit=10&status=open", Rel: "next"},
+			},
+		},
+		"offset": {
+			page: response.Page{Limit: 10, Offset: 5, HasMore: true},
+			expected: []response.Link{
+				{URL: "/todos?limit=10&status=open", Rel: "first"},
+				{URL: "/todos?limit=10&offset=0&status=open", Rel: "prev"},
+				{URL: "/todos?limit=10&offset=15&status=open", Rel: "next"},
+			},
+		},
+		"last": {
+			page:     response.Page{Limit: 10},
+			expected: []response.Link{{URL: "/todos?limit=10&status=open", Rel: "first"}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if links := test.page.Links(requestURL); !reflect.DeepEqual(test.expected, links) {
+				t.Fatalf("expected the links (%+v), got (%+v)", test.expected, links)
+			}
+		})
+	}
+}
+
+func TestRespondWithPage(t *testing.T) {
+	expected := `</todos?limit=2>; rel="first", </todos?cursor=bmV4dA&limit=2>; rel="next"`
+	for _, constructor := range []response.ResponderConstructor{response.NewJSONResponder, response.XMLEncoding.Constructor()} {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/todos?limit=2", nil)
+		constructor(zap.NewNop(), rw, r).Respond(http.StatusOK, response.Page{
+			Items:      []string{"a", "b"},
+			Limit:      2,
+			HasMore:    true,
+			NextCursor: "bmV4dA",
+		})
+		if link := rw.Header().Get("Link"); link != expected {
+			t.Errorf("expected the Link header (%s), got (%s)", expected, link)
+		}
+	}
+}
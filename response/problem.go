@@ -1,8 +1,11 @@
 package response
 
 import (
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
 	"net/http"
+	"sort"
 )
 
 type ProblemError interface {
@@ -20,13 +23,156 @@ func NewHTTPProblem(statusCode int, detail string) *Problem {
 	}
 }
 
-// Problem is a struct that provides standard error details
+// NewInvalidParamsProblem creates a new instance of a Problem for a request with
+// parameters that are not valid
+func NewInvalidParamsProblem(params []InvalidParam) *Problem {
+	problem := NewHTTPProblem(http.StatusBadRequest, "INVALID_PARAMS")
+	problem.InvalidParams = params
+	return problem
+}
+
+// InvalidParam describes why a parameter of a request is not valid
+type InvalidParam struct {
+	Name   string `json:"name" xml:"name"`
+	Reason string `json:"reason" xml:"reason"`
+}
+
+// Problem is a struct that provides standard error details, it is encoded as
+// XML in the namespace of RFC 7807. The Extensions are extension members of the
+// problem, such as retry-after or trace-id, which are encoded inline with the
+// members of the problem in JSON, and as elements of the problem in XML.
 type Problem struct {
-	Status   int    `json:"status"`
-	Type     string `json:"type"`
-	Title    string `json:"title"`
-	Detail   string `json:"detail"`
-	Instance string `json:"instance,omitempty"`
+	XMLName       xml.Name               `json:"-" xml:"urn:ietf:rfc:7807 problem"`
+	Status        int                    `json:"status" xml:"status"`
+	Type          string                 `json:"type" xml:"type"`
+	Title         string                 `json:"title" xml:"title"`
+	Detail        string                 `json:"detail" xml:"detail"`
+	Instance      string                 `json:"instance,omitempty" xml:"instance,omitempty"`
+	InvalidParams []InvalidParam         `json:"invalid-params,omitempty" xml:"invalid-params,omitempty"`
+	Extensions    map[string]interface{} `json:"-" xml:"-"`
+}
+
+// problemMemberNames are the names of the members of a Problem in JSON
+var problemMemberNames = map[string]bool{
+	"status":         true,
+	"type":           true,
+	"title":          true,
+	"detail":         true,
+	"instance":       true,
+	"invalid-params": true,
+}
+
+// problemMembers are the members of a Problem, without its methods, so that it
+// can be encoded without its extension members
+type problemMembers Problem
+
+// WithExtension sets the extension member of the problem and returns the problem
+func (p *Problem) WithExtension(name string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+// MarshalJSON encodes the problem with its extension members inline, in order of
+// their names, extension members cannot replace the members of the problem
+func (p Problem) MarshalJSON() ([]byte, error) {
+	encoded, err := json.Marshal(problemMembers(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return encoded, err
+	}
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !problemMemberNames[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	encoded = encoded[:len(encoded)-1]
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(p.Extensions[name])
+		if err != nil {
+			return nil, fmt.Errorf("could not encode the extension member (%s), got error (%w)", name, err)
+		}
+		encoded = append(append(append(append(encoded, ','), key...), ':'), value...)
+	}
+	return append(encoded, '}'), nil
+}
+
+// problemElement is an element of a problem in XML
+type problemElement struct {
+	name  string
+	value interface{}
+}
+
+// MarshalXML encodes the problem with its extension members as elements, in order
+// of their names, extension members cannot replace the members of the problem
+func (p Problem) MarshalXML(encoder *xml.Encoder, start xml.StartElement) error {
+	elements := []problemElement{
+		{name: "status", value: p.Status},
+		{name: "type", value: p.Type},
+		{name: "title", value: p.Title},
+		{name: "detail", value: p.Detail},
+	}
+	if p.Instance != "" {
+		elements = append(elements, problemElement{name: "instance", value: p.Instance})
+	}
+	if len(p.InvalidParams) > 0 {
+		elements = append(elements, problemElement{name: "invalid-params", value: p.InvalidParams})
+	}
+	names := make([]string, 0, len(p.Extensions))
+	for name := range p.Extensions {
+		if !problemMemberNames[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		elements = append(elements, problemElement{name: name, value: p.Extensions[name]})
+	}
+	start = xml.StartElement{Name: xml.Name{Space: "urn:ietf:rfc:7807", Local: "problem"}}
+	if err := encoder.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, element := range elements {
+		if err := encoder.EncodeElement(element.value, xml.StartElement{Name: xml.Name{Local: element.name}}); err != nil {
+			return fmt.Errorf("could not encode the member (%s), got error (%w)", element.name, err)
+		}
+	}
+	return encoder.EncodeToken(start.End())
+}
+
+// UnmarshalJSON decodes the problem, the members that are not members of the
+// problem are decoded into its Extensions
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	members := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	decoded := problemMembers{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	for name, value := range members {
+		if problemMemberNames[name] {
+			continue
+		}
+		var extension interface{}
+		if err := json.Unmarshal(value, &extension); err != nil {
+			return err
+		}
+		if decoded.Extensions == nil {
+			decoded.Extensions = map[string]interface{}{}
+		}
+		decoded.Extensions[name] = extension
+	}
+	*p = Problem(decoded)
+	return nil
 }
 
 // Error implements the error interface, which allows a
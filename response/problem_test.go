@@ -1,6 +1,7 @@
 package response_test
 
 import (
+	"encoding/json"
 	"net/http"
 	"reflect"
 	"testing"
@@ -21,3 +22,51 @@ func TestNewHTTPProblem(t *testing.T) {
 		t.Fatalf("expected (%+v), got (%+v)", expectedProblem, gotProblem)
 	}
 }
+
+func TestProblemExtensions(t *testing.T) {
+	problem := response.NewHTTPProblem(http.StatusTooManyRequests, "RATE_LIMITED").
+		WithExtension("retry-after", 30).
+		WithExtension("trace-id", "abc").
+		WithExtension("status", 200)
+	encoded, err := json.Marshal(problem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"status":429,"type":"https://www.w3.org/Protocols/rfc2616/rfc2616-sec10.html","title":"Too Many Requests","detail":"RATE_LIMITED","retry-after":30,"trace-id":"abc"}`
+	if string(encoded) != expected {
+		t.Fatalf("expected (%s), got (%s)", expected, encoded)
+	}
+	decoded := &response.Problem{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatal(err)
+	}
+	expectedExtensions := map[string]interface{}{"retry-after": float64(30), "trace-id": "abc"}
+	if decoded.Status != http.StatusTooManyRequests || !reflect.DeepEqual(expectedExtensions, decoded.Extensions) {
+		t.Fatalf("expected the extensions (%+v), got (%+v)", expectedExtensions, decoded)
+	}
+}
+
+func TestProblemTypes(t *testing.T) {
+	outOfStock := response.ProblemType{Type: "https://example.com/problems/out-of-stock", Title: "Out of Stock", Status: http.StatusConflict}
+	types, err := response.NewProblemTypes(outOfStock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem, err := types.New(outOfStock.Type, "OUT_OF_STOCK")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &response.Problem{Status: http.StatusConflict, Type: outOfStock.Type, Title: "Out of Stock", Detail: "OUT_OF_STOCK"}
+	if !reflect.DeepEqual(expected, problem) {
+		t.Fatalf("expected (%+v), got (%+v)", expected, problem)
+	}
+	if _, err := types.New("https://example.com/problems/unknown", ""); err == nil {
+		t.Error("expected an error for an unregistered problem type")
+	}
+	if _, err := response.NewProblemTypes(outOfStock, outOfStock); err == nil {
+		t.Error("expected an error for a problem type registered twice")
+	}
+	if _, err := response.NewProblemTypes(response.ProblemType{Type: outOfStock.Type}); err == nil {
+		t.Error("expected an error for a problem type without a status")
+	}
+}
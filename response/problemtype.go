@@ -0,0 +1,90 @@
+package response
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"go.uber.org/fx"
+)
+
+// ProblemType is a type of problem that a service responds with, the Type is a
+// stable URI that identifies the problem type, and the Title is the same for
+// every occurrence of the problem type
+type ProblemType struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Status      int    `json:"status"`
+	Description string `json:"description,omitempty"`
+}
+
+// New creates a new instance of a Problem of the problem type, with the detail
+// of the occurrence of the problem
+func (t ProblemType) New(detail string) *Problem {
+	problem := NewHTTPProblem(t.Status, detail)
+	problem.Type = t.Type
+	if t.Title != "" {
+		problem.Title = t.Title
+	}
+	return problem
+}
+
+// ProblemTypes is the registry of the problem types of a service
+type ProblemTypes struct {
+	types map[string]ProblemType
+}
+
+// ProblemTypesParams are the problem types provided to the application in the
+// "problem-types" group
+type ProblemTypesParams struct {
+	fx.In
+
+	Types []ProblemType `group:"problem-types"`
+}
+
+// NewProblemTypes creates a new registry of the problem types, each problem type
+// must have a Type and a status, and a Type can only be registered once
+func NewProblemTypes(types ...ProblemType) (ProblemTypes, error) {
+	registry := ProblemTypes{types: map[string]ProblemType{}}
+	for _, problemType := range types {
+		if problemType.Type == "" {
+			return ProblemTypes{}, fmt.Errorf("could not register the problem type (%s) without a type", problemType.Title)
+		}
+		if http.StatusText(problemType.Status) == "" {
+			return ProblemTypes{}, fmt.Errorf("could not register the problem type (%s) with the status (%d)", problemType.Type, problemType.Status)
+		}
+		if _, ok := registry.types[problemType.Type]; ok {
+			return ProblemTypes{}, fmt.Errorf("could not register the problem type (%s) more than once", problemType.Type)
+		}
+		registry.types[problemType.Type] = problemType
+	}
+	return registry, nil
+}
+
+// Lookup returns the registered problem type with the type URI
+func (p ProblemTypes) Lookup(typeURI string) (ProblemType, bool) {
+	problemType, ok := p.types[typeURI]
+	return problemType, ok
+}
+
+// New creates a new instance of a Problem of the registered problem type, it
+// returns an error when the problem type is not registered
+func (p ProblemTypes) New(typeURI string, detail string) (*Problem, error) {
+	problemType, ok := p.Lookup(typeURI)
+	if !ok {
+		return nil, fmt.Errorf("could not find the problem type (%s)", typeURI)
+	}
+	return problemType.New(detail), nil
+}
+
+// All returns the registered problem types, in order of their type URIs
+func (p ProblemTypes) All() []ProblemType {
+	types := make([]ProblemType, 0, len(p.types))
+	for _, problemType := range p.types {
+		types = append(types, problemType)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i].Type < types[j].Type
+	})
+	return types
+}
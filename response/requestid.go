@@ -0,0 +1,65 @@
+package response
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+)
+
+// RequestIDHeader is the header that carries the ID of a request
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of the context that carries the request ID
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// maxRequestIDLength is the longest X-Request-ID header that is used as the ID
+const maxRequestIDLength = 128
+
+// requestIDPattern matches the X-Request-ID headers that are used as the ID
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]+$`)
+
+// RequestID returns the ID of the request, from its context when it has been
+// set by the RequestIDMiddleware, otherwise from the X-Request-ID header when
+// it is a valid ID
+func RequestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return ""
+}
+
+// RequestIDMiddleware gives each request an ID, the X-Request-ID header of the
+// request is used when it is a valid ID, of at most 128 letters, digits, dots,
+// underscores, colons and dashes, otherwise an ID is generated. The ID is added
+// to the context of the request and to the X-Request-ID header of the response
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		rw.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(rw, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	return len(id) <= maxRequestIDLength && requestIDPattern.MatchString(id)
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(id)
+}
@@ -2,6 +2,7 @@ package response
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/BlackBX/service-framework/dependency"
 	"go.uber.org/fx"
@@ -10,11 +11,60 @@ import (
 
 // Service is the definition of the dependency
 var Service = dependency.Service{
+	Name:     "response",
+	Requires: []string{"config", "logging"},
+	ConfigFunc: func(flags dependency.FlagSet) {
+		flags.Bool(
+			"response-expose-internal-errors",
+			false,
+			"Whether the details of internal errors are exposed to clients, they are hidden by default",
+		)
+	},
 	Dependencies: fx.Provide(
 		func() ResponderConstructor {
 			return NewJSONResponder
 		},
-		NewFactory,
+		NewNegotiatingFactory,
+		NewErrorHandler,
+		func(params ProblemTypesParams) (ProblemTypes, error) {
+			return NewProblemTypes(params.Types...)
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: "application/json", Constructor: NewJSONResponder, Priority: 1}
+			},
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: string(StreamNDJSON), Constructor: NewJSONResponder}
+			},
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: string(StreamSSE), Constructor: NewJSONResponder}
+			},
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: "application/xml", Constructor: XMLEncoding.Constructor()}
+			},
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: "application/msgpack", Constructor: MessagePackEncoding.Constructor()}
+			},
+		},
+		fx.Annotated{
+			Group: "responders",
+			Target: func() ResponderRegistration {
+				return ResponderRegistration{ContentType: "application/x-protobuf", Constructor: ProtobufEncoding.Constructor()}
+			},
+		},
 	),
 	Constructor: func(factory ResponderFactory) ResponderProvider {
 		return factory
@@ -39,15 +89,27 @@ type ResponderProvider interface {
 }
 
 // ResponderFactory is a factory that can create new Responders, it allows
-// for a responder to be created in a handler and subsequently called.
+// for a responder to be created in a handler and subsequently called. When
+// there are Registrations the Responder is chosen by the Accept header of the
+// request, otherwise the DefaultResponder is always used.
 type ResponderFactory struct {
 	Logger           *zap.Logger
 	DefaultResponder ResponderConstructor
+	Registrations    []ResponderRegistration
 }
 
-// Responder creates a new instance of a responder
+// Responder creates a new instance of a responder, when none of the content types
+// accepted by the request are registered the responder responds with a 406 problem
 func (rf ResponderFactory) Responder(rw http.ResponseWriter, r *http.Request) Responder {
-	return rf.DefaultResponder(rf.Logger, rw, r)
+	if len(rf.Registrations) == 0 {
+		return rf.DefaultResponder(rf.Logger, rw, r)
+	}
+	addVary(rw.Header(), "Accept")
+	constructor, ok := rf.negotiate(r.Header.Get("Accept"))
+	if !ok {
+		return NotAcceptableResponder{Responder: rf.DefaultResponder(rf.Logger, rw, r)}
+	}
+	return constructor(rf.Logger, rw, r)
 }
 
 // Responder is an interface that abstracts the production of the
@@ -57,3 +119,15 @@ type Responder interface {
 	Respond(statusCode int, value interface{})
 	RespondStream(statusCode int, valueStream <-chan interface{})
 }
+
+// addVary adds the header name to the Vary header, unless it is already there
+func addVary(header http.Header, name string) {
+	for _, value := range header["Vary"] {
+		for _, varied := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(varied), name) {
+				return
+			}
+		}
+	}
+	header.Add("Vary", name)
+}
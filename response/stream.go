@@ -0,0 +1,184 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultHeartbeat is how often a stream of Server-Sent Events sends a heartbeat
+const DefaultHeartbeat = 15 * time.Second
+
+// StreamMode is the content type that a stream of values is responded with
+type StreamMode string
+
+// The modes that a JSONResponder can stream values in, StreamJSON writes each
+// value as a JSON document, StreamNDJSON writes newline delimited JSON, and
+// StreamSSE writes each value as a Server-Sent Event
+const (
+	StreamJSON   StreamMode = "application/json"
+	StreamNDJSON StreamMode = "application/x-ndjson"
+	StreamSSE    StreamMode = "text/event-stream"
+)
+
+// StreamResponder is a Responder that can stream values in an explicit mode
+type StreamResponder interface {
+	Responder
+	RespondStreamMode(statusCode int, mode StreamMode, valueStream <-chan interface{})
+}
+
+// StreamModeFor chooses the mode to stream values to the request in, from the
+// Accept header of the request, StreamJSON is used unless the request prefers
+// StreamNDJSON or StreamSSE
+func StreamModeFor(r *http.Request) StreamMode {
+	if r == nil {
+		return StreamJSON
+	}
+	for _, mediaType := range parseAccept(r.Header.Get("Accept")) {
+		switch StreamMode(mediaType.mediaType) {
+		case StreamNDJSON, StreamSSE:
+			return StreamMode(mediaType.mediaType)
+		}
+	}
+	return StreamJSON
+}
+
+// Event is a Server-Sent Event, values that are not Events are sent as the data
+// of an Event. Data that is a string is sent as it is, otherwise it is sent as
+// JSON. Events without an ID are given the next ID in the stream. Events with a
+// line break in their ID or Event are not sent.
+type Event struct {
+	ID    string
+	Event string
+	Data  interface{}
+	Retry time.Duration
+}
+
+// RespondStreamMode streams the values to the client in the mode, each value is
+// flushed to the client as it is written. The stream stops being written when the
+// client disconnects, the remaining values are drained so that the producer of
+// the values is not blocked, it should stop when the request context is done. When
+// the responder does not have a request, the stream is written until it is closed.
+func (r JSONResponder) RespondStreamMode(statusCode int, mode StreamMode, valueStream <-chan interface{}) {
+	r.responseWriter.Header().Set("Content-Type", string(mode))
+	var heartbeat <-chan time.Time
+	write := r.writeStreamValue
+	if mode == StreamSSE {
+		r.responseWriter.Header().Set("Cache-Control", "no-cache")
+		if r.Heartbeat > 0 {
+			ticker := time.NewTicker(r.Heartbeat)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+		write = r.newEventWriter()
+	}
+	r.responseWriter.WriteHeader(statusCode)
+	r.flush()
+	var done <-chan struct{}
+	if r.request != nil {
+		done = r.request.Context().Done()
+	}
+	for {
+		select {
+		case <-done:
+			go drain(valueStream)
+			return
+		case <-heartbeat:
+			if _, err := fmt.Fprint(r.responseWriter, ": heartbeat\n\n"); err != nil {
+				r.logger.Error("Could not write the heartbeat", zap.Error(err))
+			}
+		case value, ok := <-valueStream:
+			if !ok {
+				return
+			}
+			write(value)
+		}
+		r.flush()
+	}
+}
+
+func (r JSONResponder) writeStreamValue(value interface{}) {
+	if err := r.Encoder.Encode(value); err != nil {
+		r.logger.Error("Could not respond with value stream", zap.Any("value", value))
+	}
+}
+
+// newEventWriter creates the function that writes the values as events, the IDs of
+// the events continue from the Last-Event-ID of the request when it is a number
+func (r JSONResponder) newEventWriter() func(value interface{}) {
+	id := 0
+	if r.request != nil {
+		id, _ = strconv.Atoi(r.request.Header.Get("Last-Event-ID"))
+	}
+	return func(value interface{}) {
+		event, ok := value.(Event)
+		if !ok {
+			event = Event{Data: value}
+		}
+		if event.ID == "" {
+			id++
+			event.ID = strconv.Itoa(id)
+		}
+		encoded, err := encodeEvent(event)
+		if err != nil {
+			r.logger.Error("Could not respond with value stream", zap.Any("value", value), zap.Error(err))
+			return
+		}
+		if _, err := r.responseWriter.Write(encoded); err != nil {
+			r.logger.Error("Could not write the event", zap.Error(err))
+		}
+	}
+}
+
+// lineBreak matches the line breaks of the data of an event, which can be a CRLF,
+// a CR or an LF
+var lineBreak = regexp.MustCompile(`\r\n|\r|\n`)
+
+// encodeEvent encodes the event, the ID and the Event cannot contain a line break,
+// as it would start a new field of the event
+func encodeEvent(event Event) ([]byte, error) {
+	if strings.ContainsAny(event.ID, "\r\n") || strings.ContainsAny(event.Event, "\r\n") {
+		return nil, fmt.Errorf("could not encode the event (%q) with the id (%q), they cannot contain a line break", event.Event, event.ID)
+	}
+	data, ok := event.Data.(string)
+	if !ok {
+		buffer := &bytes.Buffer{}
+		encoder := json.NewEncoder(buffer)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(event.Data); err != nil {
+			return nil, err
+		}
+		data = strings.TrimSuffix(buffer.String(), "\n")
+	}
+	encoded := &bytes.Buffer{}
+	fmt.Fprintf(encoded, "id: %s\n", event.ID)
+	if event.Event != "" {
+		fmt.Fprintf(encoded, "event: %s\n", event.Event)
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(encoded, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	for _, line := range lineBreak.Split(data, -1) {
+		fmt.Fprintf(encoded, "data: %s\n", line)
+	}
+	encoded.WriteString("\n")
+	return encoded.Bytes(), nil
+}
+
+func (r JSONResponder) flush() {
+	if flusher, ok := r.responseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func drain(valueStream <-chan interface{}) {
+	for range valueStream {
+	}
+}
@@ -0,0 +1,137 @@
+package response_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/response"
+	"go.uber.org/zap"
+)
+
+func streamOf(values ...interface{}) <-chan interface{} {
+	stream := make(chan interface{}, len(values))
+	for _, value := range values {
+		stream <- value
+	}
+	close(stream)
+	return stream
+}
+
+func TestJSONResponderContentType(t *testing.T) {
+	tests := map[string]struct {
+		respond  func(responder response.Responder)
+		expected string
+	}{
+		"respond": {
+			respond: func(responder response.Responder) {
+				responder.Respond(http.StatusOK, Greeting{})
+			},
+			expected: "application/json",
+		},
+		"respond with problem value": {
+			respond: func(responder response.Responder) {
+				responder.Respond(http.StatusBadRequest, response.NewHTTPProblem(http.StatusBadRequest, ""))
+			},
+			expected: "application/problem+json",
+		},
+		"respond with problem": {
+			respond: func(responder response.Responder) {
+				responder.RespondWithProblem(http.StatusBadRequest, "")
+			},
+			expected: "application/problem+json",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			test.respond(response.NewJSONResponder(zap.NewNop(), rw, r))
+			if contentType := rw.Result().Header.Get("Content-Type"); contentType != test.expected {
+				t.Fatalf("expected the content type (%s), got (%s)", test.expected, contentType)
+			}
+			if contentType := r.Header.Get("Content-Type"); contentType != "" {
+				t.Fatalf("expected the request content type not to be set, got (%s)", contentType)
+			}
+		})
+	}
+}
+
+func TestJSONResponderRespondStreamNDJSON(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "application/x-ndjson")
+	response.NewJSONResponder(zap.NewNop(), rw, r).
+		RespondStream(http.StatusOK, streamOf(Greeting{Message: "a"}, Greeting{Message: "b", Count: 1}))
+	expected := "{\"message\":\"a\",\"count\":0}\n{\"message\":\"b\",\"count\":1}\n"
+	if rw.Header().Get("Content-Type") != "application/x-ndjson" || rw.Body.String() != expected {
+		t.Fatalf("expected the ndjson stream (%q), got (%s) (%q)", expected, rw.Header().Get("Content-Type"), rw.Body.String())
+	}
+	if !rw.Flushed {
+		t.Fatal("expected the stream to be flushed")
+	}
+}
+
+func TestJSONResponderRespondStreamSSE(t *testing.T) {
+	rw := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Last-Event-ID", "4")
+	responder := response.NewJSONResponder(zap.NewNop(), rw, r).(response.JSONResponder)
+	responder.RespondStreamMode(http.StatusOK, response.StreamSSE, streamOf(
+		Greeting{Message: "a"},
+		response.Event{Event: "greeting", Data: "line one\r\nline two\rline three", Retry: time.Second},
+		response.Event{ID: "custom", Data: 1},
+		response.Event{ID: "injected\ndata: forged", Data: 2},
+		response.Event{Event: "injected\r", Data: 3},
+	))
+	expected := "id: 5\ndata: {\"message\":\"a\",\"count\":0}\n\n" +
+		"id: 6\nevent: greeting\nretry: 1000\ndata: line one\ndata: line two\ndata: line three\n\n" +
+		"id: custom\ndata: 1\n\n"
+	if rw.Header().Get("Content-Type") != "text/event-stream" || rw.Body.String() != expected {
+		t.Fatalf("expected the event stream (%q), got (%s) (%q)", expected, rw.Header().Get("Content-Type"), rw.Body.String())
+	}
+}
+
+func TestJSONResponderRespondStreamWithoutRequest(t *testing.T) {
+	for _, mode := range []response.StreamMode{response.StreamNDJSON, response.StreamSSE} {
+		rw := httptest.NewRecorder()
+		responder := response.NewJSONResponder(zap.NewNop(), rw, nil).(response.JSONResponder)
+		responder.RespondStreamMode(http.StatusOK, mode, streamOf(Greeting{Message: "a"}))
+		if rw.Code != http.StatusOK || !strings.Contains(rw.Body.String(), `"message":"a"`) {
+			t.Errorf("expected the (%s) stream to be written without a request, got (%d) (%q)", mode, rw.Code, rw.Body.String())
+		}
+	}
+}
+
+func TestJSONResponderRespondStreamSSEHeartbeatAndDisconnect(t *testing.T) {
+	rw := httptest.NewRecorder()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	responder := response.NewJSONResponder(zap.NewNop(), rw, r).(response.JSONResponder)
+	responder.Heartbeat = time.Millisecond
+	stream := make(chan interface{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		responder.RespondStreamMode(http.StatusOK, response.StreamSSE, stream)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to stop when the client disconnects")
+	}
+	select {
+	case stream <- Greeting{}:
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to be drained after the client disconnects")
+	}
+	close(stream)
+	if !strings.HasPrefix(rw.Body.String(), ": heartbeat\n\n") {
+		t.Fatalf("expected heartbeats, got (%q)", rw.Body.String())
+	}
+}
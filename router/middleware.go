@@ -0,0 +1,85 @@
+package router
+
+import (
+	"reflect"
+	"runtime"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// The priorities of the middleware provided by the framework, middleware with a
+// lower priority is applied first, so it wraps middleware with a higher priority
+const (
+	PriorityRequestID   = 50
+	PriorityRecovery    = 100
+	PriorityTracing     = 200
+	PriorityLogging     = 300
+	PriorityCompression = 500
+	PriorityDefault     = 1000
+)
+
+// Middleware is middleware with a priority and a scope, middleware is provided to
+// the application in the "middleware" group. When Module is set the middleware
+// only applies to the routes of the router.Module with that path, when Route is
+// set it only applies to the route with that name, otherwise it applies to every
+// route. Middleware is ordered by priority, and then by the order it is provided in.
+type Middleware struct {
+	Name       string
+	Priority   int
+	Module     string
+	Route      string
+	Middleware mux.MiddlewareFunc
+}
+
+// global reports whether the middleware applies to every route
+func (m Middleware) global() bool {
+	return m.Module == "" && m.Route == ""
+}
+
+// NewMiddleware creates a global Middleware with the given priority, it is
+// named after the function that creates the middleware
+func NewMiddleware(priority int, middleware mux.MiddlewareFunc) Middleware {
+	return Middleware{
+		Name:       middlewareName(middleware),
+		Priority:   priority,
+		Middleware: middleware,
+	}
+}
+
+func middlewareName(middleware mux.MiddlewareFunc) string {
+	return runtime.FuncForPC(reflect.ValueOf(middleware).Pointer()).Name()
+}
+
+// SortMiddleware combines the middleware with the middleware functions, which are
+// given the default priority and come after the middleware, and sorts them by
+// priority, middleware with the same priority keeps the order it is given in
+func SortMiddleware(middlewares []Middleware, funcs []mux.MiddlewareFunc) []Middleware {
+	sorted := make([]Middleware, 0, len(middlewares)+len(funcs))
+	sorted = append(sorted, middlewares...)
+	for _, middleware := range funcs {
+		sorted = append(sorted, NewMiddleware(PriorityDefault, middleware))
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	return sorted
+}
+
+// applyRouteMiddleware wraps the handlers of the named routes with the middleware
+// scoped to them
+func applyRouteMiddleware(router *mux.Router, middlewares []Middleware) {
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		handler := route.GetHandler()
+		if route.GetName() == "" || handler == nil {
+			return nil
+		}
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			if middlewares[i].Route == route.GetName() {
+				handler = middlewares[i].Middleware(handler)
+			}
+		}
+		route.Handler(handler)
+		return nil
+	})
+}
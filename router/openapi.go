@@ -0,0 +1,386 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/gorilla/mux"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of the documents
+const OpenAPIVersion = "3.0.3"
+
+// Operation describes a named route of a Module in the OpenAPI document, the
+// types of the Request and the Responses are reflected to produce the schemas,
+// a nil response has no body. When Method is empty the operation describes
+// every method of the route, it is required for routes that match any method.
+type Operation struct {
+	Route       string
+	Method      string
+	Summary     string
+	Description string
+	Request     interface{}
+	Responses   map[int]interface{}
+}
+
+// OpenAPI is an OpenAPI 3 document
+type OpenAPI struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                       `json:"components"`
+}
+
+// OpenAPIInfo is the metadata of the API
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIOperation is an operation on a path of the API
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter is a parameter of an operation
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody is the body of the request of an operation
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse is a response of an operation
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType is the schema of a body with a content type
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// OpenAPIComponents are the schemas that are referenced by the operations
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is a JSON schema reflected from a Go type
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// NewOpenAPI generates the skeleton of an OpenAPI document from the route table of
+// the router, enriched with the Operations of the modules. Routes that match any
+// method are only included when they are described by an Operation with a Method.
+func NewOpenAPI(info OpenAPIInfo, router *mux.Router, modules []Module) OpenAPI {
+	document := OpenAPI{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   map[string]map[string]*OpenAPIOperation{},
+	}
+	schemas := newSchemaRegistry()
+	for _, route := range Routes(router, modules) {
+		for _, operation := range operationsFor(route, modules) {
+			path, parameters := openAPIPath(route.Path)
+			if document.Paths[path] == nil {
+				document.Paths[path] = map[string]*OpenAPIOperation{}
+			}
+			document.Paths[path][strings.ToLower(operation.Method)] = schemas.operation(route, operation, parameters)
+		}
+	}
+	document.Components.Schemas = schemas.schemas
+	return document
+}
+
+// operationsFor finds the operations that describe the route, routes that are not
+// described by an operation get an operation without a description
+func operationsFor(route Route, modules []Module) []Operation {
+	var operations []Operation
+	for _, module := range modules {
+		if module.PathPrefix() != route.Module || module.Version != route.Version || route.Name == "" {
+			continue
+		}
+		for _, operation := range module.Operations {
+			if operation.Route != route.Name {
+				continue
+			}
+			switch {
+			case operation.Method == "" && route.Method != AnyMethod:
+				operation.Method = route.Method
+				operations = append(operations, operation)
+			case operation.Method != "" && (route.Method == AnyMethod || strings.EqualFold(operation.Method, route.Method)):
+				operations = append(operations, operation)
+			}
+		}
+	}
+	if len(operations) == 0 && route.Method != AnyMethod {
+		operations = append(operations, Operation{Route: route.Name, Method: route.Method})
+	}
+	return operations
+}
+
+// openAPIPath converts the path template of a route to an OpenAPI path, removing
+// the patterns of the variables, and returns the variables as path parameters
+func openAPIPath(template string) (string, []OpenAPIParameter) {
+	var parameters []OpenAPIParameter
+	var path strings.Builder
+	for {
+		start := strings.Index(template, "{")
+		end := closingBrace(template, start)
+		if start == -1 || end == -1 {
+			path.WriteString(template)
+			break
+		}
+		name := strings.SplitN(template[start+1:end], ":", 2)[0]
+		path.WriteString(template[:start] + "{" + name + "}")
+		template = template[end+1:]
+		parameters = append(parameters, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+	return path.String(), parameters
+}
+
+// closingBrace finds the brace that closes the variable that starts at the given
+// index, the pattern of the variable can contain braces, such as {id:[0-9]{3}}
+func closingBrace(template string, start int) int {
+	if start == -1 {
+		return -1
+	}
+	depth := 0
+	for i := start; i < len(template); i++ {
+		switch template[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// schemaRegistry holds the schemas of the named types that have been reflected,
+// by the names of their components
+type schemaRegistry struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaRegistry() schemaRegistry {
+	return schemaRegistry{
+		schemas: map[string]*Schema{},
+		names:   map[reflect.Type]string{},
+	}
+}
+
+// name gives the name of the component of the type, which is the name of the type
+// unless it is the name of a type in another package, then it is qualified by the
+// name of its package, or by its package path
+func (s schemaRegistry) name(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+	candidates := []string{
+		t.Name(),
+		fmt.Sprintf("%s.%s", path.Base(t.PkgPath()), t.Name()),
+		fmt.Sprintf("%s.%s", componentName.ReplaceAllString(t.PkgPath(), "_"), t.Name()),
+	}
+	for _, candidate := range candidates {
+		if _, taken := s.schemas[candidate]; !taken {
+			s.names[t] = candidate
+			return candidate
+		}
+	}
+	return candidates[len(candidates)-1]
+}
+
+func (s schemaRegistry) operation(route Route, operation Operation, parameters []OpenAPIParameter) *OpenAPIOperation {
+	result := &OpenAPIOperation{
+		OperationID: route.Name,
+		Summary:     operation.Summary,
+		Description: operation.Description,
+		Parameters:  parameters,
+		Responses: map[string]OpenAPIResponse{
+			"default": {
+				Description: "A problem",
+				Content:     s.content("application/problem+json", response.Problem{}),
+			},
+		},
+	}
+	if route.Module != "" {
+		result.Tags = []string{strings.TrimPrefix(fmt.Sprintf("%s%s", route.Version, route.Module), "/")}
+	}
+	if operation.Request != nil {
+		result.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  s.content("application/json", operation.Request),
+		}
+	}
+	for status, body := range operation.Responses {
+		result.Responses[strconv.Itoa(status)] = OpenAPIResponse{
+			Description: http.StatusText(status),
+			Content:     s.content("application/json", body),
+		}
+	}
+	return result
+}
+
+func (s schemaRegistry) content(contentType string, value interface{}) map[string]OpenAPIMediaType {
+	if value == nil {
+		return nil
+	}
+	return map[string]OpenAPIMediaType{
+		contentType: {Schema: s.schema(reflect.TypeOf(value))},
+	}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	// componentName matches the characters that cannot be in the name of a component
+	componentName = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+)
+
+// schema reflects the schema of the type, named structs are added to the
+// registry and referenced
+func (s schemaRegistry) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		_, registered := s.names[t]
+		name := s.name(t)
+		if !registered {
+			s.schemas[name] = &Schema{}
+			*s.schemas[name] = *s.object(t)
+		}
+		return &Schema{Ref: fmt.Sprintf("#/components/schemas/%s", name)}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.object(t)
+	}
+	return &Schema{}
+}
+
+// object reflects the properties of a struct from its fields and their json tags,
+// fields without omitempty are required
+func (s schemaRegistry) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma != -1 {
+			name, options = tag[:comma], tag[comma:]
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := s.object(embedded)
+				for property, propertySchema := range inner.Properties {
+					schema.Properties[property] = propertySchema
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = s.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+// NewOpenAPIModule creates the module that serves the OpenAPI document of the
+// router at /openapi.json when router-openapi is set, it is provided to the
+// "admin" group
+func NewOpenAPIModule(config dependency.ConfigGetter, router *mux.Router, params Params) Module {
+	return Module{
+		Path: "openapi.json",
+		Router: func(adminRouter *mux.Router) {
+			if !config.GetBool("router-openapi") {
+				return
+			}
+			info := OpenAPIInfo{
+				Title:   config.GetString("router-openapi-title"),
+				Version: config.GetString("router-openapi-version"),
+			}
+			adminRouter.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+				params.ResponseProvider.
+					Responder(w, r).
+					Respond(http.StatusOK, NewOpenAPI(info, router, params.Modules))
+			}).Methods(http.MethodGet)
+		},
+	}
+}
@@ -0,0 +1,178 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+type Todo struct {
+	ID       int      `json:"id"`
+	Title    string   `json:"title"`
+	Tags     []string `json:"tags,omitempty"`
+	Parent   *Todo    `json:"parent,omitempty"`
+	internal bool
+}
+
+func todoModule() router.Module {
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	return router.Module{
+		Path: "todos",
+		Router: func(router *mux.Router) {
+			router.HandleFunc("", handler).Methods(http.MethodGet, http.MethodPost).Name("todos")
+			router.HandleFunc("/{id:[0-9]+}", handler).Methods(http.MethodGet).Name("todo")
+			router.HandleFunc("/any", handler)
+		},
+		Operations: []router.Operation{
+			{
+				Route:     "todos",
+				Method:    http.MethodPost,
+				Summary:   "Creates a todo",
+				Request:   Todo{},
+				Responses: map[int]interface{}{http.StatusCreated: Todo{}},
+			},
+			{
+				Route:     "todo",
+				Responses: map[int]interface{}{http.StatusOK: &Todo{}},
+			},
+		},
+	}
+}
+
+func newTodoRouter() (*mux.Router, router.Params) {
+	params := router.Params{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Modules:          []router.Module{todoModule()},
+	}
+	return router.New(params), params
+}
+
+func TestRoutes(t *testing.T) {
+	muxRouter, params := newTodoRouter()
+	expected := []router.Route{
+		{Method: http.MethodGet, Path: "/todos", Module: "/todos", Name: "todos"},
+		{Method: http.MethodPost, Path: "/todos", Module: "/todos", Name: "todos"},
+		{Method: router.AnyMethod, Path: "/todos/any", Module: "/todos"},
+		{Method: http.MethodGet, Path: "/todos/{id:[0-9]+}", Module: "/todos", Name: "todo"},
+	}
+	if routes := router.Routes(muxRouter, params.Modules); !reflect.DeepEqual(expected, routes) {
+		t.Fatalf("expected the routes to be (%+v), got (%+v)", expected, routes)
+	}
+	rw := httptest.NewRecorder()
+	adminRouter := mux.NewRouter()
+	module := router.NewRoutesModule(muxRouter, params)
+	module.Router(adminRouter.PathPrefix(module.PathPrefix()).Subrouter())
+	adminRouter.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/routes", nil))
+	var served []router.Route
+	if err := json.NewDecoder(rw.Body).Decode(&served); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, served) {
+		t.Fatalf("expected the served routes to be (%+v), got (%+v)", expected, served)
+	}
+}
+
+func TestNewOpenAPI(t *testing.T) {
+	muxRouter, params := newTodoRouter()
+	document := router.NewOpenAPI(router.OpenAPIInfo{Title: "Todos", Version: "1.0.0"}, muxRouter, params.Modules)
+	if len(document.Paths) != 2 {
+		t.Fatalf("expected (2) paths, got (%d)", len(document.Paths))
+	}
+	create := document.Paths["/todos"]["post"]
+	if create == nil || create.Summary != "Creates a todo" || create.RequestBody == nil {
+		t.Fatalf("expected the post operation to be described, got (%+v)", create)
+	}
+	if ref := create.Responses["201"].Content["application/json"].Schema.Ref; ref != "#/components/schemas/Todo" {
+		t.Errorf("expected the created response to reference the Todo schema, got (%s)", ref)
+	}
+	if list := document.Paths["/todos"]["get"]; list == nil || list.Responses["default"].Content == nil {
+		t.Errorf("expected the undescribed get operation to have the default problem response, got (%+v)", list)
+	}
+	get := document.Paths["/todos/{id}"]["get"]
+	if get == nil || len(get.Parameters) != 1 || get.Parameters[0].Name != "id" {
+		t.Fatalf("expected the get operation to have the id path parameter, got (%+v)", get)
+	}
+	expected := &router.Schema{
+		Type: "object",
+		Properties: map[string]*router.Schema{
+			"id":     {Type: "integer", Format: "int64"},
+			"title":  {Type: "string"},
+			"tags":   {Type: "array", Items: &router.Schema{Type: "string"}},
+			"parent": {Ref: "#/components/schemas/Todo"},
+		},
+		Required: []string{"id", "title"},
+	}
+	if schema := document.Components.Schemas["Todo"]; !reflect.DeepEqual(expected, schema) {
+		t.Errorf("expected the Todo schema to be (%+v), got (%+v)", expected, schema)
+	}
+	if _, ok := document.Components.Schemas["Problem"]; !ok {
+		t.Error("expected the Problem schema to be registered")
+	}
+	if _, err := json.Marshal(document); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type Problem struct {
+	Code string `json:"code"`
+}
+
+func TestNewOpenAPIQualifiesSchemasAndPatterns(t *testing.T) {
+	module := router.Module{
+		Path: "codes",
+		Router: func(router *mux.Router) {
+			router.HandleFunc("/{code:[A-Z]{3}}", func(w http.ResponseWriter, r *http.Request) {}).Methods(http.MethodGet).Name("code")
+		},
+		Operations: []router.Operation{{Route: "code", Responses: map[int]interface{}{http.StatusOK: Problem{}}}},
+	}
+	params := router.Params{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Modules:          []router.Module{module},
+	}
+	document := router.NewOpenAPI(router.OpenAPIInfo{Title: "Codes", Version: "1.0.0"}, router.New(params), params.Modules)
+	get := document.Paths["/codes/{code}"]["get"]
+	if get == nil || len(get.Parameters) != 1 || get.Parameters[0].Name != "code" {
+		t.Fatalf("expected the get operation to have the code path parameter, got (%+v)", document.Paths)
+	}
+	ref := get.Responses["200"].Content["application/json"].Schema.Ref
+	if ref != "#/components/schemas/router_test.Problem" {
+		t.Errorf("expected the response to reference the qualified Problem schema, got (%s)", ref)
+	}
+	if schema := document.Components.Schemas["router_test.Problem"]; schema == nil || schema.Properties["code"] == nil {
+		t.Errorf("expected the qualified Problem schema to be the local problem, got (%+v)", schema)
+	}
+	if schema := document.Components.Schemas["Problem"]; schema == nil || schema.Properties["status"] == nil {
+		t.Errorf("expected the Problem schema to be the problem of the response package, got (%+v)", schema)
+	}
+}
+
+func TestProblemTypesModule(t *testing.T) {
+	_, params := newTodoRouter()
+	expected := []response.ProblemType{
+		{Type: "https://example.com/problems/a", Title: "A", Status: http.StatusConflict},
+		{Type: "https://example.com/problems/b", Title: "B", Status: http.StatusGone},
+	}
+	types, err := response.NewProblemTypes(expected[1], expected[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rw := httptest.NewRecorder()
+	adminRouter := mux.NewRouter()
+	module := router.NewProblemTypesModule(types, params)
+	module.Router(adminRouter.PathPrefix(module.PathPrefix()).Subrouter())
+	adminRouter.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/problems", nil))
+	var served []response.ProblemType
+	if err := json.NewDecoder(rw.Body).Decode(&served); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expected, served) {
+		t.Fatalf("expected the served problem types to be (%+v), got (%+v)", expected, served)
+	}
+}
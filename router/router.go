@@ -2,7 +2,7 @@ package router
 
 import (
 	"fmt"
-	"net/http"
+	"io"
 	"strings"
 
 	"github.com/BlackBX/service-framework/dependency"
@@ -11,11 +11,34 @@ import (
 	"go.uber.org/fx"
 )
 
-// Service is how the dependency is provided to the dependency builder
+// Service is how the dependency is provided to the dependency builder, the route
+// table, the OpenAPI document and the problem types are served by the admin server
+// when it is used
 var Service = dependency.Service{
+	Name:     "router",
+	Requires: []string{"config", "response"},
+	ConfigFunc: func(flags dependency.FlagSet) {
+		flags.Bool("router-openapi", false, "Serve the OpenAPI document of the routes at /openapi.json on the admin server")
+		flags.String("router-openapi-title", "API", "The title of the OpenAPI document")
+		flags.String("router-openapi-version", "0.0.0", "The version of the API in the OpenAPI document")
+	},
 	Dependencies: fx.Provide(
-		func(router *mux.Router) http.Handler {
-			return router
+		NewHandler,
+		fx.Annotated{
+			Group:  "describe",
+			Target: NewDescribeSection,
+		},
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewRoutesModule,
+		},
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewOpenAPIModule,
+		},
+		fx.Annotated{
+			Group:  "admin",
+			Target: NewProblemTypesModule,
 		},
 	),
 	Constructor: New,
@@ -25,10 +48,22 @@ var Service = dependency.Service{
 // the main router
 type ApplierFunc func(router *mux.Router)
 
-// Module is a group of routes to route to based on a path
+// Module is a group of routes to route to based on a path, the Middleware of
+// the module only applies to its routes, in the order given. The Operations
+// describe the named routes of the module in the OpenAPI document.
+//
+// A module with a Version, such as v2, is served under the version, /v2/path, and
+// under the path when the version is requested in the Accept header. The path
+// without a version serves the latest version when there is no module for the
+// path without a Version. A module with a Deprecation adds the Deprecation and
+// Sunset headers to its responses.
 type Module struct {
-	Path   string
-	Router ApplierFunc
+	Path        string
+	Version     string
+	Deprecation *Deprecation
+	Router      ApplierFunc
+	Middleware  []mux.MiddlewareFunc
+	Operations  []Operation
 }
 
 // PathPrefix returns the path with a slash at the start
@@ -39,24 +74,86 @@ func (m Module) PathPrefix() string {
 	return fmt.Sprintf("/%s", m.Path)
 }
 
+// RoutePrefix returns the path prefix of the routes of the module, including
+// the version of the module
+func (m Module) RoutePrefix() string {
+	if m.Version == "" {
+		return m.PathPrefix()
+	}
+	return fmt.Sprintf("/%s%s", m.Version, m.PathPrefix())
+}
+
 // Params are the parameters required to build the router
 type Params struct {
 	fx.In
 
-	ResponseProvider response.ResponderProvider
-	Modules          []Module             `group:"server"`
-	Middlewares      []mux.MiddlewareFunc `group:"middleware"`
+	ResponseProvider  response.ResponderProvider
+	Modules           []Module             `group:"server"`
+	Middlewares       []mux.MiddlewareFunc `group:"middleware"`
+	ScopedMiddlewares []Middleware         `group:"middleware"`
 }
 
-// New creates a new instance of a *mux.Router with all of the modules added
+// New creates a new instance of a *mux.Router with all of the modules added, the
+// middleware is applied in order of priority, global middleware wraps the
+// middleware scoped to modules, which wraps the middleware scoped to routes. The
+// versions of modules are only negotiated by the handler of NewHandler.
 func New(params Params) *mux.Router {
+	middlewares := SortMiddleware(params.ScopedMiddlewares, params.Middlewares)
 	router := mux.NewRouter()
+	for _, middleware := range middlewares {
+		if middleware.global() {
+			router.Use(middleware.Middleware)
+		}
+	}
 	for _, module := range params.Modules {
-		subRouter := router.PathPrefix(module.PathPrefix()).Subrouter()
+		subRouter := router.PathPrefix(module.RoutePrefix()).Subrouter()
+		if module.Deprecation != nil {
+			subRouter.Use(module.Deprecation.Middleware)
+		}
+		for _, middleware := range middlewares {
+			if middleware.Module != "" && (Module{Path: middleware.Module}).PathPrefix() == module.PathPrefix() {
+				subRouter.Use(middleware.Middleware)
+			}
+		}
+		subRouter.Use(module.Middleware...)
 		module.Router(subRouter)
 	}
-	router.Use(params.Middlewares...)
-	router.NotFoundHandler = New404Handler(params.ResponseProvider)
+	applyRouteMiddleware(router, middlewares)
+	router.NotFoundHandler = NewVersion404Handler(params.ResponseProvider, params.Modules)
 	router.MethodNotAllowedHandler = New405Handler(params.ResponseProvider)
 	return router
 }
+
+// NewDescribeSection creates the section of the describe command that lists the
+// modules registered with the router, and the middleware in the order it is applied
+func NewDescribeSection(params Params) dependency.DescribeSection {
+	return dependency.DescribeSection{
+		Title: "Router",
+		Write: func(w io.Writer) error {
+			if _, err := fmt.Fprintln(w, "modules:"); err != nil {
+				return err
+			}
+			for _, module := range params.Modules {
+				if _, err := fmt.Fprintf(w, "  %s\n", module.RoutePrefix()); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintln(w, "middleware:"); err != nil {
+				return err
+			}
+			for i, middleware := range SortMiddleware(params.ScopedMiddlewares, params.Middlewares) {
+				scope := "global"
+				switch {
+				case middleware.Module != "":
+					scope = fmt.Sprintf("module %s", (Module{Path: middleware.Module}).PathPrefix())
+				case middleware.Route != "":
+					scope = fmt.Sprintf("route %s", middleware.Route)
+				}
+				if _, err := fmt.Fprintf(w, "  %d. %s (priority %d, %s)\n", i+1, middleware.Name, middleware.Priority, scope); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+}
@@ -3,6 +3,7 @@ package router_test
 import (
 	"context"
 	"net/http"
+	"net/http/httptest"
 	"reflect"
 	"sort"
 	"testing"
@@ -15,6 +16,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/spf13/cobra"
 	"go.uber.org/fx"
+	"go.uber.org/zap"
 )
 
 type PrinterFunc func(string, ...interface{})
@@ -89,3 +91,74 @@ func TestNew(t *testing.T) {
 		t.Fatalf("expected routes to be (%+v), got (%+v)", expectedRoutes, gotRoutes)
 	}
 }
+
+func recordingMiddleware(calls *[]string, name string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*calls = append(*calls, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestNewMiddlewareOrder(t *testing.T) {
+	var calls []string
+	params := router.Params{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Modules: []router.Module{
+			{
+				Path: "users",
+				Router: func(router *mux.Router) {
+					router.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) {
+						calls = append(calls, "handler")
+					}).Name("user")
+					router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+						calls = append(calls, "handler")
+					})
+				},
+				Middleware: []mux.MiddlewareFunc{recordingMiddleware(&calls, "module-inline")},
+			},
+		},
+		Middlewares: []mux.MiddlewareFunc{recordingMiddleware(&calls, "default")},
+		ScopedMiddlewares: []router.Middleware{
+			{Name: "route", Priority: router.PriorityRecovery, Route: "user", Middleware: recordingMiddleware(&calls, "route")},
+			{Name: "logging", Priority: router.PriorityLogging, Middleware: recordingMiddleware(&calls, "logging")},
+			{Name: "module", Priority: router.PriorityDefault, Module: "/users", Middleware: recordingMiddleware(&calls, "module")},
+			{Name: "recovery", Priority: router.PriorityRecovery, Middleware: recordingMiddleware(&calls, "recovery")},
+			{Name: "compression", Priority: router.PriorityCompression, Middleware: recordingMiddleware(&calls, "compression")},
+		},
+	}
+	handler := router.New(params)
+	tests := map[string][]string{
+		"/users/1": {"recovery", "logging", "compression", "default", "module", "module-inline", "route", "handler"},
+		"/users":   {"recovery", "logging", "compression", "default", "module", "module-inline", "handler"},
+	}
+	for path, expected := range tests {
+		calls = nil
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if !reflect.DeepEqual(expected, calls) {
+			t.Errorf("expected the middleware of (%s) to be called in the order (%v), got (%v)", path, expected, calls)
+		}
+	}
+}
+
+func TestSortMiddlewareKeepsOrderOfTies(t *testing.T) {
+	var calls []string
+	sorted := router.SortMiddleware(
+		[]router.Middleware{
+			{Name: "second", Priority: router.PriorityDefault, Middleware: recordingMiddleware(&calls, "second")},
+			{Name: "first", Priority: router.PriorityLogging, Middleware: recordingMiddleware(&calls, "first")},
+			{Name: "a", Priority: router.PriorityDefault, Middleware: recordingMiddleware(&calls, "third")},
+		},
+		[]mux.MiddlewareFunc{recordingMiddleware(&calls, "fourth"), recordingMiddleware(&calls, "fifth")},
+	)
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	for i := len(sorted) - 1; i >= 0; i-- {
+		handler = sorted[i].Middleware(handler)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	expected := []string{"first", "second", "third", "fourth", "fifth"}
+	if !reflect.DeepEqual(expected, calls) {
+		t.Fatalf("expected the middleware to be called in the order (%v), got (%v)", expected, calls)
+	}
+}
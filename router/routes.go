@@ -0,0 +1,112 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/gorilla/mux"
+)
+
+// AnyMethod is the method of routes in the route table that match any method
+const AnyMethod = "*"
+
+// Route is an entry in the route table of a router
+type Route struct {
+	Method  string `json:"method"`
+	Path    string `json:"path"`
+	Module  string `json:"module,omitempty"`
+	Version string `json:"version,omitempty"`
+	Name    string `json:"name,omitempty"`
+}
+
+// Routes walks the router and produces the route table, there is an entry for each
+// method of each route, which is sorted by path and then by method. The module of
+// a route is the module with the longest path that the route is under.
+func Routes(router *mux.Router, modules []Module) []Route {
+	var routes []Route
+	_ = router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil || len(methods) == 0 {
+			methods = []string{AnyMethod}
+		}
+		module := moduleOf(path, modules)
+		for _, method := range methods {
+			routes = append(routes, Route{
+				Method:  method,
+				Path:    path,
+				Module:  module.prefix,
+				Version: module.version,
+				Name:    route.GetName(),
+			})
+		}
+		return nil
+	})
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// routeModule is the path prefix and version of the module of a route
+type routeModule struct {
+	prefix  string
+	version string
+	route   string
+}
+
+// moduleOf finds the module that the path is under
+func moduleOf(path string, modules []Module) routeModule {
+	module := routeModule{}
+	for _, candidate := range modules {
+		prefix := candidate.RoutePrefix()
+		if path != prefix && !strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			continue
+		}
+		if len(prefix) > len(module.route) {
+			module = routeModule{prefix: candidate.PathPrefix(), version: candidate.Version, route: prefix}
+		}
+	}
+	return module
+}
+
+// NewProblemTypesModule creates the module that serves the registered problem
+// types at /problems, it is provided to the "admin" group
+func NewProblemTypesModule(types response.ProblemTypes, params Params) Module {
+	return Module{
+		Path: "problems",
+		Router: func(adminRouter *mux.Router) {
+			adminRouter.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+				params.ResponseProvider.
+					Responder(w, r).
+					Respond(http.StatusOK, types.All())
+			}).Methods(http.MethodGet)
+		},
+	}
+}
+
+// NewRoutesModule creates the module that serves the route table of the router
+// at /routes, it is provided to the "admin" group
+func NewRoutesModule(router *mux.Router, params Params) Module {
+	return Module{
+		Path: "routes",
+		Router: func(adminRouter *mux.Router) {
+			adminRouter.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+				params.ResponseProvider.
+					Responder(w, r).
+					Respond(http.StatusOK, Routes(router, params.Modules))
+			}).Methods(http.MethodGet)
+		},
+	}
+}
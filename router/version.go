@@ -0,0 +1,207 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/gorilla/mux"
+)
+
+var versionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// Deprecation is the deprecation metadata of a Module, the Deprecation and Sunset
+// headers are added to the responses of a deprecated module
+type Deprecation struct {
+	// Since is when the module was deprecated, the Deprecation header is true
+	// when it is not set
+	Since time.Time
+	// Sunset is when the module will stop being served
+	Sunset time.Time
+	// Link is a link to documentation about the deprecation
+	Link string
+}
+
+// Middleware creates the middleware that adds the deprecation headers
+func (d Deprecation) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		deprecation := "true"
+		if !d.Since.IsZero() {
+			deprecation = fmt.Sprintf("@%d", d.Since.Unix())
+		}
+		w.Header().Set("Deprecation", deprecation)
+		if !d.Sunset.IsZero() {
+			w.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Link != "" {
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Link))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// AcceptedVersion returns the version requested by the Accept header of the
+// request, with a vendor media type such as application/vnd.example.v2+json
+func AcceptedVersion(r *http.Request) string {
+	for _, mediaType := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType = strings.TrimSpace(strings.SplitN(mediaType, ";", 2)[0])
+		if !strings.HasPrefix(mediaType, "application/vnd.") || !strings.HasSuffix(mediaType, "+json") {
+			continue
+		}
+		vendor := strings.TrimSuffix(mediaType, "+json")
+		version := vendor[strings.LastIndex(vendor, ".")+1:]
+		if versionPattern.MatchString(version) {
+			return version
+		}
+	}
+	return ""
+}
+
+// compareVersions orders versions by their number
+func compareVersions(a, b string) bool {
+	numberA, _ := strconv.Atoi(strings.TrimPrefix(a, "v"))
+	numberB, _ := strconv.Atoi(strings.TrimPrefix(b, "v"))
+	return numberA < numberB
+}
+
+// versionNegotiator routes requests for the path of a versioned module without a
+// version prefix to the version in the Accept header, or to the latest version
+// when there is no version in the Accept header and no unversioned module
+type versionNegotiator struct {
+	versions    map[string][]string
+	unversioned map[string]bool
+}
+
+func newVersionNegotiator(modules []Module) versionNegotiator {
+	negotiator := versionNegotiator{
+		versions:    map[string][]string{},
+		unversioned: map[string]bool{},
+	}
+	for _, module := range modules {
+		if module.Version == "" {
+			negotiator.unversioned[module.PathPrefix()] = true
+			continue
+		}
+		negotiator.versions[module.PathPrefix()] = append(negotiator.versions[module.PathPrefix()], module.Version)
+	}
+	for _, versions := range negotiator.versions {
+		sort.Slice(versions, func(i, j int) bool {
+			return compareVersions(versions[i], versions[j])
+		})
+	}
+	return negotiator
+}
+
+// prefix finds the longest path prefix of a module that the path is under, if
+// the module is versioned
+func (n versionNegotiator) prefix(path string) string {
+	found := ""
+	for _, prefixes := range []map[string]bool{n.unversioned, versionedPrefixes(n.versions)} {
+		for prefix := range prefixes {
+			if (path == prefix || strings.HasPrefix(path, prefix+"/")) && len(prefix) > len(found) {
+				found = prefix
+			}
+		}
+	}
+	if len(n.versions[found]) == 0 {
+		return ""
+	}
+	return found
+}
+
+func versionedPrefixes(versions map[string][]string) map[string]bool {
+	prefixes := make(map[string]bool, len(versions))
+	for prefix := range versions {
+		prefixes[prefix] = true
+	}
+	return prefixes
+}
+
+// negotiate returns the version that the request for the path of a versioned
+// module without a version is routed to, or an empty string
+func (n versionNegotiator) negotiate(r *http.Request) string {
+	prefix := n.prefix(r.URL.Path)
+	if prefix == "" {
+		return ""
+	}
+	versions := n.versions[prefix]
+	version := AcceptedVersion(r)
+	if version == "" {
+		if n.unversioned[prefix] {
+			return ""
+		}
+		version = versions[len(versions)-1]
+	}
+	for _, available := range versions {
+		if available == version {
+			return version
+		}
+	}
+	return ""
+}
+
+// Middleware routes the requests for the path of a versioned module without a
+// version to the negotiated version, with a copy of the request that has the
+// version in its path, so that the request is not changed while it is matched
+func (n versionNegotiator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if version := n.negotiate(r); version != "" {
+			r = r.Clone(r.Context())
+			r.URL.Path = fmt.Sprintf("/%s%s", version, r.URL.Path)
+			r.URL.RawPath = ""
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NewHandler creates the http.Handler of the router, which serves the requests
+// for the path of a versioned module without a version by the negotiated version
+func NewHandler(router *mux.Router, params Params) http.Handler {
+	negotiator := newVersionNegotiator(params.Modules)
+	if len(negotiator.versions) == 0 {
+		return router
+	}
+	return negotiator.Middleware(router)
+}
+
+// missingVersion reports whether the request is for a version, either in the
+// Accept header or the path, that is not served
+func (n versionNegotiator) missingVersion(r *http.Request) bool {
+	path := r.URL.Path
+	version := AcceptedVersion(r)
+	segments := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	if len(segments) == 2 && versionPattern.MatchString(segments[0]) {
+		version, path = segments[0], "/"+segments[1]
+	}
+	prefix := n.prefix(path)
+	if version == "" || prefix == "" {
+		return false
+	}
+	for _, available := range n.versions[prefix] {
+		if available == version {
+			return false
+		}
+	}
+	return true
+}
+
+// NewVersion404Handler produces the 404 handler of a router with versioned
+// modules, which explains when the requested version is not served
+func NewVersion404Handler(provider response.ResponderProvider, modules []Module) http.Handler {
+	negotiator := newVersionNegotiator(modules)
+	notFound := New404Handler(provider)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if !negotiator.missingVersion(r) {
+			notFound.ServeHTTP(rw, r)
+			return
+		}
+		provider.
+			Responder(rw, r).
+			RespondWithProblem(http.StatusNotFound, "VERSION_NOT_FOUND")
+	})
+}
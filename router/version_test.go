@@ -0,0 +1,94 @@
+package router_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+func versionModule(path, version string, deprecation *router.Deprecation) router.Module {
+	return router.Module{
+		Path:        path,
+		Version:     version,
+		Deprecation: deprecation,
+		Router: func(router *mux.Router) {
+			router.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Version", version)
+			})
+		},
+	}
+}
+
+func TestNewVersions(t *testing.T) {
+	since := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+	params := router.Params{
+		ResponseProvider: response.NewFactory(zap.NewNop(), response.NewJSONResponder),
+		Modules: []router.Module{
+			versionModule("todos", "v2", nil),
+			versionModule("todos", "v1", &router.Deprecation{Since: since, Sunset: sunset, Link: "https://example.com/v2"}),
+			versionModule("users", "", nil),
+			versionModule("users", "v2", nil),
+		},
+	}
+	muxRouter := router.New(params)
+	handler := router.NewHandler(muxRouter, params)
+	tests := []struct {
+		path        string
+		accept      string
+		status      int
+		version     string
+		deprecation string
+		detail      string
+	}{
+		{path: "/v1/todos/1", status: http.StatusOK, version: "v1", deprecation: "@1704067200"},
+		{path: "/v2/todos/1", status: http.StatusOK, version: "v2"},
+		{path: "/todos/1", accept: "application/vnd.example.v1+json", status: http.StatusOK, version: "v1", deprecation: "@1704067200"},
+		{path: "/todos/1", status: http.StatusOK, version: "v2"},
+		{path: "/users/1", status: http.StatusOK},
+		{path: "/users/1", accept: "application/vnd.example.v2+json; q=0.9, application/json", status: http.StatusOK, version: "v2"},
+		{path: "/todos/1", accept: "application/vnd.example.v3+json", status: http.StatusNotFound, detail: "VERSION_NOT_FOUND"},
+		{path: "/v3/todos/1", status: http.StatusNotFound, detail: "VERSION_NOT_FOUND"},
+		{path: "/v2/todos", status: http.StatusNotFound, detail: "ROUTE_NOT_FOUND"},
+	}
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		r.Header.Set("Accept", test.accept)
+		muxRouter.Match(r, &mux.RouteMatch{})
+		handler.ServeHTTP(rw, r)
+		if r.URL.Path != test.path {
+			t.Errorf("expected the path of the request to be kept (%s), got (%s)", test.path, r.URL.Path)
+		}
+		if rw.Code != test.status {
+			t.Errorf("expected (%s) with accept (%s) to respond with (%d), got (%d)", test.path, test.accept, test.status, rw.Code)
+			continue
+		}
+		if version := rw.Header().Get("X-Version"); version != test.version {
+			t.Errorf("expected (%s) with accept (%s) to be served by version (%s), got (%s)", test.path, test.accept, test.version, version)
+		}
+		if deprecation := rw.Header().Get("Deprecation"); deprecation != test.deprecation {
+			t.Errorf("expected (%s) to have the deprecation header (%s), got (%s)", test.path, test.deprecation, deprecation)
+		}
+		if test.deprecation != "" && rw.Header().Get("Sunset") != "Wed, 01 Jan 2025 00:00:00 GMT" {
+			t.Errorf("expected (%s) to have the sunset header, got (%s)", test.path, rw.Header().Get("Sunset"))
+		}
+		if test.detail == "" {
+			continue
+		}
+		problem := response.Problem{}
+		if err := json.NewDecoder(rw.Body).Decode(&problem); err != nil {
+			t.Fatal(err)
+		}
+		if problem.Detail != test.detail {
+			t.Errorf("expected (%s) to respond with the detail (%s), got (%s)", test.path, test.detail, problem.Detail)
+		}
+	}
+}
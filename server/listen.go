@@ -0,0 +1,144 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/BlackBX/service-framework/dependency"
+)
+
+// listenFDsStart is the first file descriptor passed by systemd socket activation
+const listenFDsStart = 3
+
+// SocketConfig is the configuration of the unix socket that the server listens
+// on, when server-listen is a unix:// address
+type SocketConfig struct {
+	Mode os.FileMode
+}
+
+// NewSocketConfig creates the SocketConfig from the configuration
+func NewSocketConfig(getter dependency.ConfigGetter) (SocketConfig, error) {
+	mode := getter.GetString("server-socket-mode")
+	if mode == "" {
+		return SocketConfig{}, nil
+	}
+	parsed, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return SocketConfig{}, fmt.Errorf("the socket mode (%s) is not an octal file mode, got error (%w)", mode, err)
+	}
+	return SocketConfig{Mode: os.FileMode(parsed)}, nil
+}
+
+// Listen binds the listener for the address of the server, the address is either
+// host:port, or a URL that is one of tcp://host:port, unix:///path/to.sock,
+// fd://3 or systemd:// for socket activation. When systemd passes more than one
+// socket, the socket is chosen by its name in LISTEN_FDNAMES, systemd://name.
+func Listen(server *http.Server) (net.Listener, error) {
+	addr := server.Addr
+	if addr == "" {
+		addr = ":http"
+		if server.TLSConfig != nil {
+			addr = ":https"
+		}
+	}
+	listener, err := listen(addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on (%s), got error (%w)", addr, err)
+	}
+	return listener, nil
+}
+
+func listen(addr string) (net.Listener, error) {
+	if !strings.Contains(addr, "://") {
+		return net.Listen("tcp", addr)
+	}
+	address, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+	switch address.Scheme {
+	case "tcp":
+		return net.Listen("tcp", address.Host)
+	case "unix":
+		path, _ := SocketPath(addr)
+		if err := removeSocket(path); err != nil {
+			return nil, err
+		}
+		return net.Listen("unix", path)
+	case "fd":
+		fd, err := strconv.Atoi(address.Host)
+		if err != nil {
+			return nil, fmt.Errorf("the file descriptor (%s) is not a number", address.Host)
+		}
+		return fileListener(fd)
+	case "systemd":
+		fd, err := socketActivated(address.Host)
+		if err != nil {
+			return nil, err
+		}
+		return fileListener(fd)
+	}
+	return nil, fmt.Errorf("the scheme (%s) is not supported", address.Scheme)
+}
+
+// SocketPath returns the path of the unix socket, if the address is a unix:// address
+func SocketPath(addr string) (string, bool) {
+	if !strings.HasPrefix(addr, "unix://") {
+		return "", false
+	}
+	return strings.TrimPrefix(addr, "unix://"), true
+}
+
+// removeSocket removes a socket that has been left behind by a previous process,
+// any other type of file is left in place
+func removeSocket(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("the file (%s) exists, and is not a socket", path)
+	}
+	return os.Remove(path)
+}
+
+func fileListener(fd int) (net.Listener, error) {
+	file := os.NewFile(uintptr(fd), fmt.Sprintf("fd://%d", fd))
+	if file == nil {
+		return nil, fmt.Errorf("the file descriptor (%d) is not valid", fd)
+	}
+	defer file.Close()
+	return net.FileListener(file)
+}
+
+// socketActivated finds the file descriptor of the socket with the name that has
+// been passed to the process by systemd, when there is no name there must be only
+// one socket
+func socketActivated(name string) (int, error) {
+	if pid, err := strconv.Atoi(os.Getenv("LISTEN_PID")); err != nil || pid != os.Getpid() {
+		return 0, errors.New("the process was not socket activated, LISTEN_PID does not match")
+	}
+	fds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || fds < 1 {
+		return 0, errors.New("the process was not socket activated, LISTEN_FDS is not set")
+	}
+	if name == "" {
+		if fds > 1 {
+			return 0, fmt.Errorf("the process was passed (%d) sockets, the name of the socket must be given", fds)
+		}
+		return listenFDsStart, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for i := 0; i < fds && i < len(names); i++ {
+		if names[i] == name {
+			return listenFDsStart + i, nil
+		}
+	}
+	return 0, fmt.Errorf("the process was not passed a socket named (%s) in LISTEN_FDNAMES", name)
+}
@@ -0,0 +1,127 @@
+package server_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/BlackBX/service-framework/server"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+)
+
+func TestInvokeUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server-listen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "server.sock")
+	srv := &http.Server{
+		Addr:    "unix://" + path,
+		Handler: http.HandlerFunc(writeProtocol),
+	}
+	app := fxtest.New(t,
+		fx.NopLogger,
+		fx.Provide(
+			zap.NewNop,
+			func() server.Server {
+				return srv
+			},
+			func() server.SocketConfig {
+				return server.SocketConfig{Mode: 0600}
+			},
+		),
+		fx.Invoke(server.Invoke),
+	)
+	app.RequireStart()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("expected the socket mode to be (%s), got (%s)", os.FileMode(0600), info.Mode().Perm())
+	}
+	client := &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		},
+	}
+	res, err := client.Get("http://unix/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expected status (%d), got (%d)", http.StatusOK, res.StatusCode)
+	}
+	app.RequireStop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket to be removed, got error (%v)", err)
+	}
+}
+
+func TestListen(t *testing.T) {
+	listener, err := server.Listen(&http.Server{Addr: "tcp://127.0.0.1:0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	file, err := listener.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	fdListener, err := server.Listen(&http.Server{Addr: fmt.Sprintf("fd://%d", file.Fd())})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fdListener.Close()
+	if fdListener.Addr().String() != listener.Addr().String() {
+		t.Fatalf("expected the address to be (%s), got (%s)", listener.Addr(), fdListener.Addr())
+	}
+}
+
+func TestListenSystemdFails(t *testing.T) {
+	env := map[string]string{
+		"LISTEN_PID":     strconv.Itoa(os.Getpid()),
+		"LISTEN_FDS":     "2",
+		"LISTEN_FDNAMES": "http:admin",
+	}
+	for key, value := range env {
+		if err := os.Setenv(key, value); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Unsetenv(key)
+	}
+	for _, addr := range []string{"systemd://", "systemd://missing"} {
+		if listener, err := server.Listen(&http.Server{Addr: addr}); err == nil {
+			_ = listener.Close()
+			t.Errorf("expected an error listening on (%s), got none", addr)
+		}
+	}
+}
+
+func TestListenFails(t *testing.T) {
+	file, err := ioutil.TempFile("", "server-listen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	_ = file.Close()
+	for _, addr := range []string{"systemd://", "fd://three", "udp://127.0.0.1:0", "unix://" + file.Name()} {
+		if listener, err := server.Listen(&http.Server{Addr: addr}); err == nil {
+			_ = listener.Close()
+			t.Errorf("expected an error listening on (%s), got none", addr)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
+	"github.com/gorilla/mux"
+	"github.com/heptiolabs/healthcheck"
+	"github.com/spf13/pflag"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Named creates a service for an additional server with the given name, the
+// flags of the server are prefixed with the name, for example internal-server-port.
+// The server has its own router, built from the router.Modules in the group with
+// the name of the server, and the middleware in the group with the name of the
+// server suffixed with -middleware, for example "internal" and "internal-middleware".
+// The *http.Server is provided with the name of the server. The port of the server
+// defaults to 0, so that it listens on a free port rather than the port of the main
+// server, and is meant to be set with its flag.
+func Named(name string) dependency.Service {
+	return dependency.Service{
+		Name:     fmt.Sprintf("server-%s", name),
+		Requires: []string{"config", "logging", "response"},
+		ConfigFunc: func(flags dependency.FlagSet) {
+			prefixFlags(flags, name, Service.ConfigFunc, namedDefaults)
+		},
+		Dependencies: fx.Provide(fx.Annotated{
+			Name:   name,
+			Target: namedConstructor(name),
+		}),
+		InvokeFunc: namedInvoke(name),
+	}
+}
+
+// namedDefaults are the defaults of the flags of named servers that differ from
+// the defaults of the main server
+var namedDefaults = map[string]string{
+	"server-port": "0",
+}
+
+// prefixFlags registers the flags of the registerer with the given prefix, each
+// flag is registered with the type and default of the unprefixed flag, unless it
+// has a default in defaults
+func prefixFlags(flags dependency.FlagSet, prefix string, registerer dependency.ConfigRegisterer, defaults map[string]string) {
+	unprefixed := pflag.NewFlagSet(prefix, pflag.ContinueOnError)
+	registerer(unprefixed)
+	for name, value := range defaults {
+		if err := unprefixed.Set(name, value); err != nil {
+			panic(fmt.Sprintf("server: cannot set the default of flag (%s), got error (%s)", name, err))
+		}
+	}
+	unprefixed.VisitAll(func(flag *pflag.Flag) {
+		if err := prefixFlag(flags, unprefixed, prefix, flag); err != nil {
+			panic(fmt.Sprintf("server: cannot prefix flag (%s), got error (%s)", flag.Name, err))
+		}
+	})
+}
+
+// prefixFlag registers the flag with the given prefix, the flags have not been parsed
+// so the values of the unprefixed flags are their defaults. Flags of other types are
+// registered with their pflag.Value, which belongs to the unprefixed flags alone.
+// nolint: gocyclo
+func prefixFlag(flags dependency.FlagSet, unprefixed *pflag.FlagSet, prefix string, flag *pflag.Flag) error {
+	name, usage := fmt.Sprintf("%s-%s", prefix, flag.Name), flag.Usage
+	switch flag.Value.Type() {
+	case "string":
+		flags.String(name, flag.Value.String(), usage)
+	case "bool":
+		value, _ := unprefixed.GetBool(flag.Name)
+		flags.Bool(name, value, usage)
+	case "int":
+		value, _ := unprefixed.GetInt(flag.Name)
+		flags.Int(name, value, usage)
+	case "int32":
+		value, _ := unprefixed.GetInt32(flag.Name)
+		flags.Int32(name, value, usage)
+	case "int64":
+		value, _ := unprefixed.GetInt64(flag.Name)
+		flags.Int64(name, value, usage)
+	case "uint":
+		value, _ := unprefixed.GetUint(flag.Name)
+		flags.Uint(name, value, usage)
+	case "uint32":
+		value, _ := unprefixed.GetUint32(flag.Name)
+		flags.Uint32(name, value, usage)
+	case "uint64":
+		value, _ := unprefixed.GetUint64(flag.Name)
+		flags.Uint64(name, value, usage)
+	case "float64":
+		value, _ := unprefixed.GetFloat64(flag.Name)
+		flags.Float64(name, value, usage)
+	case "duration":
+		value, _ := unprefixed.GetDuration(flag.Name)
+		flags.Duration(name, value, usage)
+	case "stringSlice":
+		value, _ := unprefixed.GetStringSlice(flag.Name)
+		flags.StringSlice(name, value, usage)
+	case "intSlice":
+		value, _ := unprefixed.GetIntSlice(flag.Name)
+		flags.IntSlice(name, value, usage)
+	case "stringToString":
+		value, _ := unprefixed.GetStringToString(flag.Name)
+		flags.StringToString(name, value, usage)
+	default:
+		set, ok := flags.(interface {
+			Var(value pflag.Value, name string, usage string)
+		})
+		if !ok {
+			return fmt.Errorf("the type (%s) is not supported", flag.Value.Type())
+		}
+		set.Var(flag.Value, name, usage)
+	}
+	return nil
+}
+
+// inType creates the type of an fx.In struct with the given fields
+func inType(fields ...reflect.StructField) reflect.Type {
+	in := reflect.StructField{Name: "In", Type: reflect.TypeOf(fx.In{}), Anonymous: true}
+	return reflect.StructOf(append([]reflect.StructField{in}, fields...))
+}
+
+func field(name string, value interface{}, tag string) reflect.StructField {
+	return reflect.StructField{
+		Name: name,
+		Type: reflect.TypeOf(value).Elem(),
+		Tag:  reflect.StructTag(tag),
+	}
+}
+
+// namedConstructor creates the constructor of the *http.Server of a named server
+func namedConstructor(name string) interface{} {
+	paramsType := inType(
+		field("Config", (*dependency.ConfigGetter)(nil), ""),
+		field("ResponseProvider", (*response.ResponderProvider)(nil), ""),
+		field("Modules", (*[]router.Module)(nil), fmt.Sprintf(`group:"%s"`, name)),
+		field("Middlewares", (*[]mux.MiddlewareFunc)(nil), fmt.Sprintf(`group:"%s-middleware"`, name)),
+		field("ScopedMiddlewares", (*[]router.Middleware)(nil), fmt.Sprintf(`group:"%s-middleware"`, name)),
+	)
+	constructorType := reflect.FuncOf(
+		[]reflect.Type{paramsType},
+		[]reflect.Type{reflect.TypeOf(&http.Server{}), errorType},
+		false,
+	)
+	return reflect.MakeFunc(constructorType, func(args []reflect.Value) []reflect.Value {
+		params := args[0]
+		routerParams := router.Params{
+			ResponseProvider:  params.FieldByName("ResponseProvider").Interface().(response.ResponderProvider),
+			Modules:           params.FieldByName("Modules").Interface().([]router.Module),
+			Middlewares:       params.FieldByName("Middlewares").Interface().([]mux.MiddlewareFunc),
+			ScopedMiddlewares: params.FieldByName("ScopedMiddlewares").Interface().([]router.Middleware),
+		}
+		handler := router.NewHandler(router.New(routerParams), routerParams)
+		config := prefixedGetter{
+			prefix: name,
+			getter: params.FieldByName("Config").Interface().(dependency.ConfigGetter),
+		}
+		server, err := New(handler, config)
+		errValue := reflect.Zero(errorType)
+		if err != nil {
+			err = fmt.Errorf("could not create server (%s), got error (%w)", name, err)
+			errValue = reflect.ValueOf(&err).Elem()
+		}
+		return []reflect.Value{reflect.ValueOf(server), errValue}
+	}).Interface()
+}
+
+// namedInvoke creates the function that starts the named server
+func namedInvoke(name string) interface{} {
+	paramsType := inType(
+		field("Lifecycle", (*fx.Lifecycle)(nil), ""),
+		field("Shutdowner", (*fx.Shutdowner)(nil), ""),
+		field("Config", (*dependency.ConfigGetter)(nil), ""),
+		field("Logger", (**zap.Logger)(nil), ""),
+		field("Server", (**http.Server)(nil), fmt.Sprintf(`name:"%s"`, name)),
+		field("Check", (*healthcheck.Handler)(nil), `optional:"true"`),
+	)
+	invokeType := reflect.FuncOf([]reflect.Type{paramsType}, []reflect.Type{errorType}, false)
+	return reflect.MakeFunc(invokeType, func(args []reflect.Value) []reflect.Value {
+		params := args[0]
+		config := prefixedGetter{
+			prefix: name,
+			getter: params.FieldByName("Config").Interface().(dependency.ConfigGetter),
+		}
+		check, _ := params.FieldByName("Check").Interface().(healthcheck.Handler)
+		socket, err := NewSocketConfig(config)
+		errValue := reflect.Zero(errorType)
+		if err != nil {
+			errValue = reflect.ValueOf(&err).Elem()
+			return []reflect.Value{errValue}
+		}
+		invoke(Params{
+			Lifecycle:  params.FieldByName("Lifecycle").Interface().(fx.Lifecycle),
+			Shutdowner: params.FieldByName("Shutdowner").Interface().(fx.Shutdowner),
+			Server:     params.FieldByName("Server").Interface().(*http.Server),
+			Logger:     params.FieldByName("Logger").Interface().(*zap.Logger).With(zap.String("server", name)),
+			Shutdown:   NewShutdownConfig(config),
+			Socket:     socket,
+			Check:      check,
+		}, fmt.Sprintf("server-%s-draining", name))
+		return []reflect.Value{errValue}
+	}).Interface()
+}
+
+// prefixedGetter is a dependency.ConfigGetter that prefixes the keys read from it
+type prefixedGetter struct {
+	prefix string
+	getter dependency.ConfigGetter
+}
+
+func (g prefixedGetter) key(key string) string {
+	return fmt.Sprintf("%s-%s", g.prefix, key)
+}
+
+func (g prefixedGetter) GetString(key string) string {
+	return g.getter.GetString(g.key(key))
+}
+
+func (g prefixedGetter) GetBool(key string) bool {
+	return g.getter.GetBool(g.key(key))
+}
+
+func (g prefixedGetter) GetInt(key string) int {
+	return g.getter.GetInt(g.key(key))
+}
+
+func (g prefixedGetter) GetInt32(key string) int32 {
+	return g.getter.GetInt32(g.key(key))
+}
+
+func (g prefixedGetter) GetInt64(key string) int64 {
+	return g.getter.GetInt64(g.key(key))
+}
+
+func (g prefixedGetter) GetUint(key string) uint {
+	return g.getter.GetUint(g.key(key))
+}
+
+func (g prefixedGetter) GetUint32(key string) uint32 {
+	return g.getter.GetUint32(g.key(key))
+}
+
+func (g prefixedGetter) GetUint64(key string) uint64 {
+	return g.getter.GetUint64(g.key(key))
+}
+
+func (g prefixedGetter) GetFloat64(key string) float64 {
+	return g.getter.GetFloat64(g.key(key))
+}
+
+func (g prefixedGetter) GetTime(key string) time.Time {
+	return g.getter.GetTime(g.key(key))
+}
+
+func (g prefixedGetter) GetDuration(key string) time.Duration {
+	return g.getter.GetDuration(g.key(key))
+}
+
+func (g prefixedGetter) GetIntSlice(key string) []int {
+	return g.getter.GetIntSlice(g.key(key))
+}
+
+func (g prefixedGetter) GetStringSlice(key string) []string {
+	return g.getter.GetStringSlice(g.key(key))
+}
+
+func (g prefixedGetter) GetStringMap(key string) map[string]interface{} {
+	return g.getter.GetStringMap(g.key(key))
+}
+
+func (g prefixedGetter) GetStringMapString(key string) map[string]string {
+	return g.getter.GetStringMapString(g.key(key))
+}
+
+func (g prefixedGetter) GetStringMapStringSlice(key string) map[string][]string {
+	return g.getter.GetStringMapStringSlice(g.key(key))
+}
@@ -0,0 +1,203 @@
+package server_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/dependency"
+	"github.com/BlackBX/service-framework/logging"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/router"
+	"github.com/BlackBX/service-framework/server"
+	"github.com/gorilla/mux"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.uber.org/fx"
+)
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func moduleWithPath(group, path string) fx.Annotated {
+	return fx.Annotated{
+		Group: group,
+		Target: func() router.Module {
+			return router.Module{
+				Path: path,
+				Router: func(router *mux.Router) {
+					router.HandleFunc("", writeProtocol)
+				},
+			}
+		},
+	}
+}
+
+type namedParams struct {
+	fx.In
+
+	Server *http.Server `name:"internal"`
+}
+
+func TestNamed(t *testing.T) {
+	publicPort, internalPort := freePort(t), freePort(t)
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(logging.Service).
+		WithService(response.Service).
+		WithService(router.Service).
+		WithService(server.Service).
+		WithService(server.Named("internal")).
+		WithModule(fx.Provide(
+			moduleWithPath("server", "public"),
+			moduleWithPath("internal", "internal"),
+			fx.Annotated{
+				Group: "internal",
+				Target: func() router.Module {
+					return router.Module{
+						Path:    "versioned",
+						Version: "v1",
+						Router: func(router *mux.Router) {
+							router.HandleFunc("", writeProtocol)
+						},
+					}
+				},
+			},
+			fx.Annotated{
+				Group: "internal-middleware",
+				Target: func() mux.MiddlewareFunc {
+					return func(next http.Handler) http.Handler {
+						return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+							w.Header().Set("X-Internal", "true")
+							next.ServeHTTP(w, r)
+						})
+					}
+				},
+			},
+		)).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{
+		"--server-host=127.0.0.1",
+		fmt.Sprintf("--server-port=%d", publicPort),
+		"--internal-server-host=127.0.0.1",
+		fmt.Sprintf("--internal-server-port=%d", internalPort),
+	})
+	type result struct {
+		status   int
+		internal string
+	}
+	results := map[string]result{}
+	var internalServer *http.Server
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		app := builder.
+			WithInvoke(func(params namedParams) {
+				internalServer = params.Server
+			}).
+			BuildTest(t)
+		app.RequireStart()
+		defer app.RequireStop()
+		for _, url := range []string{
+			fmt.Sprintf("http://127.0.0.1:%d/public", publicPort),
+			fmt.Sprintf("http://127.0.0.1:%d/internal", publicPort),
+			fmt.Sprintf("http://127.0.0.1:%d/internal", internalPort),
+			fmt.Sprintf("http://127.0.0.1:%d/public", internalPort),
+			fmt.Sprintf("http://127.0.0.1:%d/versioned", internalPort),
+		} {
+			res, err := http.Get(url)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+			results[url] = result{status: res.StatusCode, internal: res.Header.Get("X-Internal")}
+		}
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]result{
+		fmt.Sprintf("http://127.0.0.1:%d/public", publicPort):      {status: http.StatusOK},
+		fmt.Sprintf("http://127.0.0.1:%d/internal", publicPort):    {status: http.StatusNotFound},
+		fmt.Sprintf("http://127.0.0.1:%d/internal", internalPort):  {status: http.StatusOK, internal: "true"},
+		fmt.Sprintf("http://127.0.0.1:%d/public", internalPort):    {status: http.StatusNotFound},
+		fmt.Sprintf("http://127.0.0.1:%d/versioned", internalPort): {status: http.StatusOK, internal: "true"},
+	}
+	if expectedAddr := fmt.Sprintf("127.0.0.1:%d", internalPort); internalServer == nil || internalServer.Addr != expectedAddr {
+		t.Fatalf("expected the internal server to be provided with address (%s)", expectedAddr)
+	}
+	for url, expectedResult := range expected {
+		if results[url] != expectedResult {
+			t.Errorf("expected (%s) to respond with (%+v), got (%+v)", url, expectedResult, results[url])
+		}
+	}
+}
+
+func TestNamedFlags(t *testing.T) {
+	unprefixed := pflag.NewFlagSet("server", pflag.ContinueOnError)
+	server.Service.ConfigFunc(unprefixed)
+	prefixed := pflag.NewFlagSet("internal", pflag.ContinueOnError)
+	server.Named("internal").ConfigFunc(prefixed)
+	unprefixed.VisitAll(func(flag *pflag.Flag) {
+		name := fmt.Sprintf("internal-%s", flag.Name)
+		prefixedFlag := prefixed.Lookup(name)
+		if prefixedFlag == nil {
+			t.Errorf("expected the flag (%s) to be registered", name)
+			return
+		}
+		defValue := flag.DefValue
+		if flag.Name == "server-port" {
+			defValue = "0"
+		}
+		if prefixedFlag.Value.Type() != flag.Value.Type() || prefixedFlag.DefValue != defValue {
+			t.Errorf(
+				"expected the flag (%s) to be a (%s) with default (%s), got a (%s) with default (%s)",
+				name, flag.Value.Type(), defValue, prefixedFlag.Value.Type(), prefixedFlag.DefValue,
+			)
+		}
+	})
+}
+
+func TestNamedDefaultFlags(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:8080")
+	if err != nil {
+		t.Skipf("the default port is not free, got error (%s)", err)
+	}
+	_ = listener.Close()
+	cmd := &cobra.Command{}
+	builder := dependency.NewBuilder(cmd).
+		WithService(config.Service).
+		WithService(logging.Service).
+		WithService(response.Service).
+		WithService(router.Service).
+		WithService(server.Service).
+		WithService(server.Named("internal")).
+		WithModule(fx.Provide(moduleWithPath("server", "public"))).
+		WithModule(fx.NopLogger)
+	cmd.SetArgs([]string{"--server-host=127.0.0.1"})
+	status := 0
+	cmd.Run = func(cmd *cobra.Command, args []string) {
+		app := builder.BuildTest(t)
+		app.RequireStart()
+		defer app.RequireStop()
+		res, err := http.Get("http://127.0.0.1:8080/public")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		status = res.StatusCode
+	}
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("expected the main server to respond with (%d), got (%d)", http.StatusOK, status)
+	}
+}
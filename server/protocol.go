@@ -0,0 +1,69 @@
+package server
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// The values of the server-protocol flag
+const (
+	ProtocolHTTP1 = "http1"
+	ProtocolH2C   = "h2c"
+	ProtocolH2    = "h2"
+)
+
+// ConfigureProtocol configures the server to serve the given protocol, http1
+// serves only HTTP/1.1, h2c serves HTTP/2 without TLS as well as HTTP/1.1, and
+// h2 serves HTTP/2 over TLS, negotiated with ALPN. The h2c connections are sent
+// GOAWAY when the server is shut down. HTTP/3 is not supported, it needs a QUIC
+// listener that the standard library does not provide.
+func ConfigureProtocol(server *http.Server, protocol string) error {
+	switch protocol {
+	case ProtocolHTTP1:
+		if server.TLSConfig != nil {
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+	case ProtocolH2C:
+		h2Server := &http2.Server{IdleTimeout: server.IdleTimeout}
+		// configuring the server shuts down the HTTP/2 connections with it, the TLS
+		// config is restored and HTTP/2 over TLS is disabled as h2c does not
+		// negotiate HTTP/2 over TLS
+		tlsConfig := server.TLSConfig
+		server.TLSConfig = tlsConfig.Clone()
+		if err := http2.ConfigureServer(server, h2Server); err != nil {
+			return fmt.Errorf("could not configure HTTP/2, got error (%w)", err)
+		}
+		server.TLSConfig, server.TLSNextProto = tlsConfig, nil
+		if tlsConfig != nil {
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+		server.Handler = h2c.NewHandler(server.Handler, h2Server)
+	case ProtocolH2:
+		if server.TLSConfig == nil {
+			return errors.New("the h2 protocol requires server-tls-cert to be set")
+		}
+		if err := http2.ConfigureServer(server, &http2.Server{IdleTimeout: server.IdleTimeout}); err != nil {
+			return fmt.Errorf("could not configure HTTP/2, got error (%w)", err)
+		}
+	default:
+		return fmt.Errorf("the protocol (%s) is not supported", protocol)
+	}
+	return nil
+}
+
+// NegotiatedProtocol returns the protocol that the request was made with, as
+// one of the values of the server-protocol flag
+func NegotiatedProtocol(r *http.Request) string {
+	if r.ProtoMajor != 2 {
+		return ProtocolHTTP1
+	}
+	if r.TLS != nil {
+		return ProtocolH2
+	}
+	return ProtocolH2C
+}
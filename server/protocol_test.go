@@ -0,0 +1,167 @@
+package server_test
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/response"
+	"github.com/BlackBX/service-framework/server"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"golang.org/x/net/http2"
+)
+
+func startServer(t *testing.T, handler http.Handler, args ...string) (*http.Server, string, error) {
+	cmd := &cobra.Command{}
+	server.Service.ConfigFunc(cmd.PersistentFlags())
+	cmd.SetArgs(args)
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := server.New(handler, cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		if srv.TLSConfig != nil {
+			_ = srv.ServeTLS(listener, "", "")
+			return
+		}
+		_ = srv.Serve(listener)
+	}()
+	return srv, listener.Addr().String(), nil
+}
+
+func writeProtocol(w http.ResponseWriter, r *http.Request) {
+	_, _ = w.Write([]byte(server.NegotiatedProtocol(r)))
+}
+
+func TestProtocolH2C(t *testing.T) {
+	received := make(chan struct{})
+	srv, addr, err := startServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values := make(chan interface{})
+		go func() {
+			defer close(values)
+			values <- server.NegotiatedProtocol(r)
+			<-received
+			values <- "done"
+		}()
+		response.NewJSONResponder(zap.NewNop(), w, r).RespondStream(http.StatusOK, values)
+	}), "--server-protocol=h2c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+		Timeout: 5 * time.Second,
+	}
+	res, err := client.Get("http://" + addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.ProtoMajor != 2 {
+		t.Fatalf("expected HTTP/2, got (%s)", res.Proto)
+	}
+	reader := bufio.NewReader(res.Body)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "\"h2c\"\n" {
+		t.Fatalf("expected the first value to be flushed as (\"h2c\"), got (%s)", line)
+	}
+	close(received)
+	if line, err = reader.ReadString('\n'); err != nil || line != "\"done\"\n" {
+		t.Fatalf("expected the second value to be (\"done\"), got (%s) with error (%v)", line, err)
+	}
+}
+
+func TestProtocolTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server-protocol")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "ca", nil, true)
+	serverCertificate := newTestCertificate(t, "server", ca, false)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeFile(t, certFile, serverCertificate.certPEM, time.Now())
+	writeFile(t, keyFile, serverCertificate.keyPEM, time.Now())
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+
+	tests := []struct {
+		protocol         string
+		expectedMajor    int
+		expectedProtocol string
+	}{
+		{protocol: server.ProtocolHTTP1, expectedMajor: 1, expectedProtocol: server.ProtocolHTTP1},
+		{protocol: server.ProtocolH2, expectedMajor: 2, expectedProtocol: server.ProtocolH2},
+		{protocol: server.ProtocolH2C, expectedMajor: 1, expectedProtocol: server.ProtocolHTTP1},
+	}
+	for _, test := range tests {
+		t.Run(test.protocol, func(t *testing.T) {
+			srv, addr, err := startServer(
+				t,
+				http.HandlerFunc(writeProtocol),
+				"--server-tls-cert="+certFile,
+				"--server-tls-key="+keyFile,
+				"--server-protocol="+test.protocol,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer srv.Close()
+			client := &http.Client{
+				Transport: &http.Transport{
+					TLSClientConfig:   &tls.Config{RootCAs: roots},
+					ForceAttemptHTTP2: true,
+				},
+			}
+			res, err := client.Get("https://" + addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer res.Body.Close()
+			body, err := ioutil.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.ProtoMajor != test.expectedMajor || string(body) != test.expectedProtocol {
+				t.Fatalf("expected protocol (%s), got (%s) negotiated as (%s)", test.expectedProtocol, res.Proto, body)
+			}
+		})
+	}
+}
+
+func TestProtocolFails(t *testing.T) {
+	for _, protocol := range []string{server.ProtocolH2, "spdy"} {
+		if _, _, err := startServer(t, http.HandlerFunc(writeProtocol), "--server-protocol="+protocol); err == nil {
+			t.Fatalf("expected an error for protocol (%s), got none", protocol)
+		}
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/BlackBX/service-framework/dependency"
+	"github.com/heptiolabs/healthcheck"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 )
@@ -15,6 +17,8 @@ import (
 // Service allows the service to be used in the dependency builder
 // nolint: gomnd
 var Service = dependency.Service{
+	Name:     "server",
+	Requires: []string{"config", "logging", "router"},
 	ConfigFunc: func(flags dependency.FlagSet) {
 		flags.String("server-host", "0.0.0.0", "The IP to start on")
 		flags.Int("server-port", 8080, "The port to start the web Server on")
@@ -23,9 +27,25 @@ var Service = dependency.Service{
 		flags.Duration("write-timeout", 20*time.Second, "The write timeout for the HTTP Server")
 		flags.Duration("idle-timeout", 10*time.Second, "The idle timeout for the HTTP Server")
 		flags.Int("max-header-bytes", http.DefaultMaxHeaderBytes, "The maximum size that the HTTP header can be in bytes")
+		flags.Duration("server-shutdown-grace", 10*time.Second, "The maximum time to wait for in-flight requests when shutting down")
+		flags.Duration("server-drain-delay", 0, "The time to wait after failing the readiness check before shutting down")
+		flags.String("server-tls-cert", "", "The PEM encoded certificate file to serve TLS with, it is reloaded when it changes")
+		flags.String("server-tls-key", "", "The PEM encoded key file of the TLS certificate, it is reloaded when it changes")
+		flags.Duration("server-tls-reload-interval", 10*time.Second, "How often the TLS certificate files are checked for changes")
+		flags.String("server-tls-client-ca", "", "The PEM encoded CA file that client certificates must be signed by")
+		flags.String("server-tls-min-version", "1.2", "The minimum version of TLS to accept (1.0/1.1/1.2/1.3)")
+		flags.String("server-protocol", ProtocolHTTP1, "The protocol to serve (http1/h2c/h2), h2 requires TLS")
+		flags.String(
+			"server-listen",
+			"",
+			"The address to listen on (tcp://host:port, unix:///path.sock, fd://3, systemd:// or systemd://name), overrides server-host and server-port",
+		)
+		flags.String("server-socket-mode", "0660", "The file permissions of the unix socket that the server listens on")
 	},
 	Dependencies: fx.Provide(
 		New,
+		NewShutdownConfig,
+		NewSocketConfig,
 	),
 	InvokeFunc: Invoke,
 	Constructor: func(server *http.Server) Server {
@@ -34,34 +54,90 @@ var Service = dependency.Service{
 }
 
 // New creates a new instance of the *http.Server configured by the config
-// you decided
-func New(router http.Handler, getter dependency.ConfigGetter) *http.Server {
-	return &http.Server{
-		Addr:              fmt.Sprintf("%s:%d", getter.GetString("server-host"), getter.GetInt("server-port")),
+// you decided, when server-tls-client-ca is set the verified certificate of
+// the client is available to handlers via ClientCertificate. The protocol that
+// requests are made with is available to handlers via NegotiatedProtocol.
+func New(router http.Handler, getter dependency.ConfigGetter) (*http.Server, error) {
+	tlsConfig, err := NewTLSConfig(getter)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure TLS, got error (%w)", err)
+	}
+	if tlsConfig != nil && tlsConfig.ClientCAs != nil {
+		router = ClientCertificates(router)
+	}
+	addr := getter.GetString("server-listen")
+	if addr == "" {
+		addr = fmt.Sprintf("%s:%d", getter.GetString("server-host"), getter.GetInt("server-port"))
+	}
+	server := &http.Server{
+		Addr:              addr,
 		Handler:           router,
+		TLSConfig:         tlsConfig,
 		ReadTimeout:       getter.GetDuration("read-timeout"),
 		ReadHeaderTimeout: getter.GetDuration("read-header-timeout"),
 		WriteTimeout:      getter.GetDuration("write-timeout"),
 		IdleTimeout:       getter.GetDuration("idle-timeout"),
 		MaxHeaderBytes:    getter.GetInt("max-header-bytes"),
 	}
+	if err := ConfigureProtocol(server, getter.GetString("server-protocol")); err != nil {
+		return nil, err
+	}
+	return server, nil
 }
 
 // Params are the dependencies required to start the server
 type Params struct {
 	fx.In
 
-	Lifecycle fx.Lifecycle
-	Server    Server
-	Logger    *zap.Logger
+	Lifecycle  fx.Lifecycle
+	Shutdowner fx.Shutdowner
+	Server     Server
+	Logger     *zap.Logger
+	Shutdown   ShutdownConfig      `optional:"true"`
+	Socket     SocketConfig        `optional:"true"`
+	Check      healthcheck.Handler `optional:"true"`
 }
 
-// Invoke is the function that is called to start the server
+// Invoke is the function that is called to start the server, when it is stopped
+// the readiness check fails, and the server is gracefully shut down. If the server
+// listens on a unix socket, the permissions of the socket are set before it starts
+// serving, and the socket is removed once it has stopped.
 func Invoke(params Params) {
-	params.Lifecycle.Append(fx.Hook{
-		OnStart: StartServer(params.Server, params.Logger),
-		OnStop:  StopServer(params.Server, params.Logger),
-	})
+	invoke(params, "server-draining")
+}
+
+// invoke starts the server, adding a readiness check with the given name that
+// fails when the server is stopped
+func invoke(params Params, checkName string) {
+	shutdown := NewShutdown(params.Shutdown, params.Logger)
+	hook := fx.Hook{
+		OnStart: startServer(params.Server, params.Logger, params.Shutdowner, params.Socket),
+		OnStop:  shutdown.Stop(params.Server),
+	}
+	if server, ok := params.Server.(*http.Server); ok {
+		shutdown.Connections.Track(server)
+		if path, ok := SocketPath(server.Addr); ok {
+			hook = socketHook(hook, path)
+		}
+	}
+	if params.Check != nil {
+		params.Check.AddReadinessCheck(checkName, shutdown.ReadinessCheck)
+	}
+	params.Lifecycle.Append(hook)
+}
+
+// socketHook removes the unix socket at the path once the server has stopped
+func socketHook(hook fx.Hook, path string) fx.Hook {
+	return fx.Hook{
+		OnStart: hook.OnStart,
+		OnStop: func(ctx context.Context) error {
+			err := hook.OnStop(ctx)
+			if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+				err = fmt.Errorf("could not remove socket (%s), got error (%w)", path, removeErr)
+			}
+			return err
+		},
+	}
 }
 
 // Server is an interface that abstracts the *http.Server
@@ -70,27 +146,63 @@ type Server interface {
 	Shutdown(ctx context.Context) error
 }
 
-// StartServer creates a closure that will start the server when called
-func StartServer(server Server, logger *zap.Logger) func(ctx context.Context) error {
+// StartServer creates a closure that will start the server when called, if the
+// server is a *http.Server its listener is bound before the closure returns, so
+// that a failure to bind fails the start of the application. If the server
+// stops serving unexpectedly, the application is shut down.
+func StartServer(server Server, logger *zap.Logger, shutdowner fx.Shutdowner) func(ctx context.Context) error {
+	return startServer(server, logger, shutdowner, SocketConfig{})
+}
+
+// startServer starts the server, when it listens on a unix socket the mode of the
+// socket is set before it starts serving
+func startServer(server Server, logger *zap.Logger, shutdowner fx.Shutdowner, socket SocketConfig) func(ctx context.Context) error {
 	return func(ctx context.Context) error {
 		logger.Info("Starting HTTP Server")
-		go func() {
-			if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		httpServer, ok := server.(*http.Server)
+		if !ok {
+			go serve(server.ListenAndServe, "Could not start Server", logger, shutdowner)
+			return nil
+		}
+		listener, err := Listen(httpServer)
+		if err != nil {
+			logger.Error("Could not start Server", zap.Error(err))
+			return err
+		}
+		if path, ok := SocketPath(httpServer.Addr); ok && socket.Mode != 0 {
+			if err := os.Chmod(path, socket.Mode); err != nil {
+				_ = listener.Close()
+				err = fmt.Errorf("could not set the mode of socket (%s), got error (%w)", path, err)
 				logger.Error("Could not start Server", zap.Error(err))
+				return err
+			}
+		}
+		serveFunc := func() error {
+			return httpServer.Serve(listener)
+		}
+		if httpServer.TLSConfig != nil {
+			serveFunc = func() error {
+				return httpServer.ServeTLS(listener, "", "")
 			}
-		}()
+		}
+		go serve(serveFunc, "HTTP Server stopped unexpectedly", logger, shutdowner)
 		return nil
 	}
 }
 
-// StopServer creates a closure that will stop the server
-func StopServer(server Server, logger *zap.Logger) func(ctx context.Context) error {
-	return func(ctx context.Context) error {
-		logger.Info("Stopping HTTP Server")
-		if err := server.Shutdown(context.Background()); err != nil {
-			logger.Error("Error when shutting down Server")
-			return fmt.Errorf("error shutting down Server (%w)", err)
-		}
-		return nil
+func serve(serveFunc func() error, message string, logger *zap.Logger, shutdowner fx.Shutdowner) {
+	err := serveFunc()
+	if err == nil || errors.Is(err, http.ErrServerClosed) {
+		return
+	}
+	logger.Error(message, zap.Error(err))
+	if err := shutdowner.Shutdown(); err != nil {
+		logger.Error("Could not shut down the application", zap.Error(err))
 	}
 }
+
+// StopServer creates a closure that will stop the server, the shutdown is
+// bounded by the context given to the closure
+func StopServer(server Server, logger *zap.Logger) func(ctx context.Context) error {
+	return NewShutdown(ShutdownConfig{}, logger).Stop(server)
+}
@@ -2,7 +2,9 @@ package server_test
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
+	"net"
 	"net/http"
 	"reflect"
 	"sync"
@@ -145,9 +147,19 @@ func TestNew(t *testing.T) {
 	}
 }
 
+type shutdownerFunc func(opts ...fx.ShutdownOption) error
+
+func (f shutdownerFunc) Shutdown(opts ...fx.ShutdownOption) error {
+	return f(opts...)
+}
+
 func TestStartServerFails(t *testing.T) {
 	wg := new(sync.WaitGroup)
-	wg.Add(1)
+	wg.Add(2)
+	shutdowner := shutdownerFunc(func(opts ...fx.ShutdownOption) error {
+		wg.Done()
+		return nil
+	})
 	srv := stubServer{
 		listenAndServe: func() error {
 			wg.Done()
@@ -170,7 +182,7 @@ func TestStartServerFails(t *testing.T) {
 		t,
 		zaptest.WrapOptions(hooks),
 	)
-	err := server.StartServer(srv, logger)(context.Background())
+	err := server.StartServer(srv, logger, shutdowner)(context.Background())
 	if err != nil {
 		t.Fatalf("expected error to be nil, got (%s)", err)
 	}
@@ -180,6 +192,44 @@ func TestStartServerFails(t *testing.T) {
 	}
 }
 
+func TestStartServerBindFails(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	shutdowner := shutdownerFunc(func(opts ...fx.ShutdownOption) error {
+		t.Fatal("expected the application not to be shut down")
+		return nil
+	})
+	srv := &http.Server{Addr: listener.Addr().String()}
+	if err := server.StartServer(srv, zap.NewNop(), shutdowner)(context.Background()); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
+
+func TestStartServerServeFails(t *testing.T) {
+	shutdown := make(chan struct{})
+	shutdowner := shutdownerFunc(func(opts ...fx.ShutdownOption) error {
+		close(shutdown)
+		return nil
+	})
+	srv := &http.Server{
+		Addr: "127.0.0.1:0",
+		TLSConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+	if err := server.StartServer(srv, zap.NewNop(), shutdowner)(context.Background()); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	select {
+	case <-shutdown:
+	case <-time.After(time.Second):
+		t.Fatal("expected the application to be shut down")
+	}
+}
+
 func TestStopServerFails(t *testing.T) {
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+// ShutdownConfig is the configuration of the graceful shutdown of the server,
+// DrainDelay is how long the server waits after failing its readiness check
+// before it stops accepting connections, and Grace is the maximum time given
+// to in-flight requests to complete before the connections are closed
+type ShutdownConfig struct {
+	Grace      time.Duration
+	DrainDelay time.Duration
+}
+
+// NewShutdownConfig creates the ShutdownConfig from the configuration
+func NewShutdownConfig(getter dependency.ConfigGetter) ShutdownConfig {
+	return ShutdownConfig{
+		Grace:      getter.GetDuration("server-shutdown-grace"),
+		DrainDelay: getter.GetDuration("server-drain-delay"),
+	}
+}
+
+// NewConnections creates a new instance of the *Connections type
+func NewConnections() *Connections {
+	return &Connections{
+		states:   map[net.Conn]http.ConnState{},
+		hijacked: map[net.Conn]struct{}{},
+	}
+}
+
+// Connections tracks the state of the connections of a *http.Server, including
+// the connections that are hijacked to serve h2c, which the server does not track
+type Connections struct {
+	mutex    sync.Mutex
+	states   map[net.Conn]http.ConnState
+	hijacked map[net.Conn]struct{}
+}
+
+// connKey is the key of the connection of a request in its context
+type connKey struct{}
+
+// Track records the state of the connections of the server, any ConnState and
+// ConnContext functions already set on the server are still called. A hijacked
+// connection is tracked until the handler that hijacked it returns, as the h2c
+// handler does once the HTTP/2 connection is closed.
+func (c *Connections) Track(server *http.Server) {
+	previous := server.ConnState
+	server.ConnState = func(conn net.Conn, state http.ConnState) {
+		c.set(conn, state)
+		if previous != nil {
+			previous(conn, state)
+		}
+	}
+	previousContext := server.ConnContext
+	server.ConnContext = func(ctx context.Context, conn net.Conn) context.Context {
+		if previousContext != nil {
+			ctx = previousContext(ctx, conn)
+		}
+		return context.WithValue(ctx, connKey{}, conn)
+	}
+	handler := server.Handler
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+	server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer c.release(r.Context())
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (c *Connections) set(conn net.Conn, state http.ConnState) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	switch state {
+	case http.StateHijacked:
+		delete(c.states, conn)
+		c.hijacked[conn] = struct{}{}
+	case http.StateClosed:
+		delete(c.states, conn)
+	default:
+		c.states[conn] = state
+	}
+}
+
+// release stops tracking the connection of the request if it was hijacked
+func (c *Connections) release(ctx context.Context) {
+	conn, ok := ctx.Value(connKey{}).(net.Conn)
+	if !ok {
+		return
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.hijacked, conn)
+}
+
+// Active returns the number of connections that are handling a request, or that
+// have been hijacked
+func (c *Connections) Active() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	active := len(c.hijacked)
+	for _, state := range c.states {
+		if state == http.StateActive {
+			active++
+		}
+	}
+	return active
+}
+
+// WaitHijacked waits for the hijacked connections to be closed, or for the
+// context to be done
+func (c *Connections) WaitHijacked(ctx context.Context) error {
+	ticker := time.NewTicker(hijackedPollInterval)
+	defer ticker.Stop()
+	for {
+		c.mutex.Lock()
+		hijacked := len(c.hijacked)
+		c.mutex.Unlock()
+		if hijacked == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CloseHijacked closes the hijacked connections
+func (c *Connections) CloseHijacked() error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	var err error
+	for conn := range c.hijacked {
+		if closeErr := conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+		delete(c.hijacked, conn)
+	}
+	return err
+}
+
+const hijackedPollInterval = 10 * time.Millisecond
+
+// NewShutdown creates a new instance of the *Shutdown type
+func NewShutdown(config ShutdownConfig, logger *zap.Logger) *Shutdown {
+	return &Shutdown{
+		Config:      config,
+		Logger:      logger,
+		Connections: NewConnections(),
+		draining:    atomic.NewBool(false),
+	}
+}
+
+// Shutdown gracefully stops a Server, the readiness check of the Shutdown fails
+// as soon as the server begins to stop, so that traffic is routed elsewhere
+type Shutdown struct {
+	Config      ShutdownConfig
+	Logger      *zap.Logger
+	Connections *Connections
+	draining    *atomic.Bool
+}
+
+// ReadinessCheck fails once the server has begun to stop
+func (s *Shutdown) ReadinessCheck() error {
+	if s.draining.Load() {
+		return errors.New("the HTTP server is shutting down")
+	}
+	return nil
+}
+
+// Stop creates a closure that will drain, and then stop the server, the shutdown
+// is bounded by the context given to the closure and the grace period, and waits
+// for the hijacked connections to close. If the server can be closed, lingering
+// connections are closed once the shutdown times out.
+func (s *Shutdown) Stop(server Server) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		s.Logger.Info("Stopping HTTP Server")
+		s.draining.Store(true)
+		if s.Config.DrainDelay > 0 {
+			s.Logger.Info("Draining HTTP Server", zap.Duration("delay", s.Config.DrainDelay))
+			select {
+			case <-time.After(s.Config.DrainDelay):
+			case <-ctx.Done():
+			}
+		}
+		if s.Config.Grace > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, s.Config.Grace)
+			defer cancel()
+		}
+		err := server.Shutdown(ctx)
+		if err == nil {
+			err = s.Connections.WaitHijacked(ctx)
+		}
+		if err == nil {
+			return nil
+		}
+		closer, ok := server.(io.Closer)
+		if !ok || !(errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled)) {
+			s.Logger.Error("Error when shutting down Server")
+			return fmt.Errorf("error shutting down Server (%w)", err)
+		}
+		s.Logger.Warn("Closing HTTP Server connections", zap.Int("in-flight", s.Connections.Active()))
+		if err := closer.Close(); err != nil {
+			s.Logger.Error("Error when closing Server")
+			return fmt.Errorf("error closing Server (%w)", err)
+		}
+		if err := s.Connections.CloseHijacked(); err != nil {
+			s.Logger.Error("Error when closing hijacked connections")
+			return fmt.Errorf("error closing hijacked connections (%w)", err)
+		}
+		return nil
+	}
+}
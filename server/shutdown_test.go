@@ -0,0 +1,185 @@
+package server_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"golang.org/x/net/http2"
+)
+
+func TestShutdown_StopClosesLingeringConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+	core, logs := observer.New(zap.InfoLevel)
+	shutdown := server.NewShutdown(server.ShutdownConfig{Grace: 50 * time.Millisecond}, zap.New(core))
+	shutdown.Connections.Track(srv)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	go func() {
+		response, err := http.Get("http://" + listener.Addr().String())
+		if err == nil {
+			_ = response.Body.Close()
+		}
+	}()
+	<-started
+	if err := shutdown.ReadinessCheck(); err != nil {
+		t.Fatalf("expected the server to be ready, got (%s)", err)
+	}
+	if err := shutdown.Stop(srv)(context.Background()); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if err := shutdown.ReadinessCheck(); err == nil {
+		t.Fatal("expected the server not to be ready")
+	}
+	closing := logs.FilterMessage("Closing HTTP Server connections").All()
+	if len(closing) != 1 {
+		t.Fatalf("expected the connections to be closed, got logs (%+v)", logs.All())
+	}
+	if inFlight := closing[0].ContextMap()["in-flight"]; inFlight != int64(1) {
+		t.Fatalf("expected (1) in-flight connection, got (%v)", inFlight)
+	}
+}
+
+func TestShutdown_StopWaitsForDrainDelay(t *testing.T) {
+	drainDelay := 20 * time.Millisecond
+	shutdown := server.NewShutdown(server.ShutdownConfig{DrainDelay: drainDelay}, zap.NewNop())
+	var ready error
+	var waited time.Duration
+	start := time.Now()
+	srv := stubServer{
+		shutdown: func(ctx context.Context) error {
+			waited = time.Since(start)
+			ready = shutdown.ReadinessCheck()
+			return nil
+		},
+	}
+	if err := shutdown.Stop(srv)(context.Background()); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if ready == nil {
+		t.Fatal("expected the readiness check to fail while draining")
+	}
+	if waited < drainDelay {
+		t.Fatalf("expected shutdown to wait at least (%s), waited (%s)", drainDelay, waited)
+	}
+}
+
+func TestShutdown_StopClosesHijackedConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-release
+		}),
+	}
+	if err := server.ConfigureProtocol(srv, server.ProtocolH2C); err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zap.InfoLevel)
+	shutdown := server.NewShutdown(server.ShutdownConfig{Grace: 50 * time.Millisecond}, zap.New(core))
+	shutdown.Connections.Track(srv)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	client := &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+				return net.Dial(network, addr)
+			},
+		},
+	}
+	failed := make(chan error, 1)
+	go func() {
+		response, err := client.Get("http://" + listener.Addr().String())
+		if err == nil {
+			_ = response.Body.Close()
+		}
+		failed <- err
+	}()
+	<-started
+	if active := shutdown.Connections.Active(); active != 1 {
+		t.Fatalf("expected the hijacked connection to be active, got (%d) active connections", active)
+	}
+	if err := shutdown.Stop(srv)(context.Background()); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if closing := logs.FilterMessage("Closing HTTP Server connections").All(); len(closing) != 1 {
+		t.Fatalf("expected the connections to be closed, got logs (%+v)", logs.All())
+	}
+	select {
+	case err := <-failed:
+		if err == nil {
+			t.Fatal("expected the request on the closed connection to fail")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the hijacked connection to be closed")
+	}
+}
+
+func TestShutdown_StopWaitsForIdleHijackedConnections(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	if err := server.ConfigureProtocol(srv, server.ProtocolH2C); err != nil {
+		t.Fatal(err)
+	}
+	core, logs := observer.New(zap.InfoLevel)
+	grace := 10 * time.Second
+	shutdown := server.NewShutdown(server.ShutdownConfig{Grace: grace}, zap.New(core))
+	shutdown.Connections.Track(srv)
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	transport := &http2.Transport{
+		AllowHTTP: true,
+		DialTLS: func(network, addr string, cfg *tls.Config) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+	response, err := (&http.Client{Transport: transport}).Get("http://" + listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = response.Body.Close()
+	start := time.Now()
+	if err := shutdown.Stop(srv)(context.Background()); err != nil {
+		t.Fatalf("expected no error, got (%s)", err)
+	}
+	if waited := time.Since(start); waited > grace/2 {
+		t.Fatalf("expected the idle connection to be shut down with GOAWAY, waited (%s)", waited)
+	}
+	if closing := logs.FilterMessage("Closing HTTP Server connections").All(); len(closing) != 0 {
+		t.Fatalf("expected the connections not to be closed, got logs (%+v)", logs.All())
+	}
+	if active := shutdown.Connections.Active(); active != 0 {
+		t.Fatalf("expected no active connections, got (%d)", active)
+	}
+}
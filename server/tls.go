@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/BlackBX/service-framework/dependency"
+)
+
+type contextKey string
+
+const clientCertificateKey contextKey = "client-certificate"
+
+// TLSVersions are the values of the server-tls-min-version flag
+var TLSVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig creates the *tls.Config of the server from the configuration, if
+// server-tls-cert has not been set then no *tls.Config is returned. When
+// server-tls-client-ca is set clients must present a certificate signed by it.
+func NewTLSConfig(getter dependency.ConfigGetter) (*tls.Config, error) {
+	certFile := getter.GetString("server-tls-cert")
+	if certFile == "" {
+		return nil, nil
+	}
+	version, ok := TLSVersions[getter.GetString("server-tls-min-version")]
+	if !ok {
+		return nil, fmt.Errorf("the TLS version (%s) is not supported", getter.GetString("server-tls-min-version"))
+	}
+	reloader, err := NewCertificateReloader(
+		certFile,
+		getter.GetString("server-tls-key"),
+		getter.GetDuration("server-tls-reload-interval"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		MinVersion:     version,
+		GetCertificate: reloader.GetCertificate,
+	}
+	clientCA := getter.GetString("server-tls-client-ca")
+	if clientCA == "" {
+		return config, nil
+	}
+	contents, err := ioutil.ReadFile(clientCA)
+	if err != nil {
+		return nil, fmt.Errorf("could not read client CA (%s), got error (%w)", clientCA, err)
+	}
+	config.ClientCAs = x509.NewCertPool()
+	if !config.ClientCAs.AppendCertsFromPEM(contents) {
+		return nil, fmt.Errorf("the client CA (%s) does not contain any PEM encoded certificates", clientCA)
+	}
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return config, nil
+}
+
+// NewCertificateReloader creates a new instance of the *CertificateReloader type
+// that checks the files for changes at most once per interval, returning an error
+// if the certificate cannot be loaded
+func NewCertificateReloader(certFile, keyFile string, interval time.Duration) (*CertificateReloader, error) {
+	reloader := &CertificateReloader{
+		CertFile: certFile,
+		KeyFile:  keyFile,
+		Interval: interval,
+	}
+	if err := reloader.Reload(); err != nil {
+		return nil, err
+	}
+	return reloader, nil
+}
+
+// CertificateReloader loads a certificate and key pair, reloading them when
+// the files on disk change. If the files cannot be reloaded, the previously
+// loaded certificate continues to be used. The certificate is held in an
+// atomic.Value so that handshakes do not wait for it to be reloaded.
+type CertificateReloader struct {
+	CertFile    string
+	KeyFile     string
+	Interval    time.Duration
+	certificate atomic.Value
+	checked     int64
+	reloading   int32
+	mutex       sync.Mutex
+	modified    time.Time
+}
+
+// GetCertificate returns the current certificate, it is used as the
+// GetCertificate function of a *tls.Config. When the files have not been
+// checked within the Interval they are reloaded in the background.
+func (r *CertificateReloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	checked := atomic.LoadInt64(&r.checked)
+	if time.Now().UnixNano()-checked >= int64(r.Interval) && atomic.CompareAndSwapInt32(&r.reloading, 0, 1) {
+		go func() {
+			defer atomic.StoreInt32(&r.reloading, 0)
+			_ = r.Reload()
+		}()
+	}
+	certificate, _ := r.certificate.Load().(*tls.Certificate)
+	if certificate == nil {
+		return nil, fmt.Errorf("the certificate (%s) has not been loaded", r.CertFile)
+	}
+	return certificate, nil
+}
+
+// Reload loads the certificate if the files have changed since it was loaded
+func (r *CertificateReloader) Reload() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	defer atomic.StoreInt64(&r.checked, time.Now().UnixNano())
+	modified, err := r.lastModified()
+	if err != nil {
+		return err
+	}
+	if !modified.After(r.modified) {
+		return nil
+	}
+	certificate, err := tls.LoadX509KeyPair(r.CertFile, r.KeyFile)
+	if err != nil {
+		return fmt.Errorf("could not load certificate (%s), got error (%w)", r.CertFile, err)
+	}
+	r.certificate.Store(&certificate)
+	r.modified = modified
+	return nil
+}
+
+func (r *CertificateReloader) lastModified() (time.Time, error) {
+	var modified time.Time
+	for _, file := range []string{r.CertFile, r.KeyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not read certificate file (%s), got error (%w)", file, err)
+		}
+		if info.ModTime().After(modified) {
+			modified = info.ModTime()
+		}
+	}
+	return modified, nil
+}
+
+// ClientCertificates is middleware that adds the verified certificate of the
+// client to the context of the request
+func ClientCertificates(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			ctx := context.WithValue(r.Context(), clientCertificateKey, r.TLS.VerifiedChains[0][0])
+			r = r.WithContext(ctx)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientCertificate returns the verified certificate of the client from the
+// context of the request
+func ClientCertificate(ctx context.Context) (*x509.Certificate, bool) {
+	certificate, ok := ctx.Value(clientCertificateKey).(*x509.Certificate)
+	return certificate, ok
+}
+
+// ClientSubject returns the subject of the verified certificate of the client
+// from the context of the request
+func ClientSubject(ctx context.Context) (pkix.Name, bool) {
+	certificate, ok := ClientCertificate(ctx)
+	if !ok {
+		return pkix.Name{}, false
+	}
+	return certificate.Subject, true
+}
@@ -0,0 +1,202 @@
+package server_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/BlackBX/service-framework/config"
+	"github.com/BlackBX/service-framework/server"
+	"github.com/spf13/cobra"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+	keyPEM      []byte
+}
+
+func newTestCertificate(t *testing.T, name string, parent *testCertificate, isCA bool) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	parentCertificate, parentKey := template, key
+	if parent != nil {
+		parentCertificate, parentKey = parent.certificate, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCertificate, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:      pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func writeFile(t *testing.T, path string, contents []byte, modified time.Time) {
+	if err := ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNewServesMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "server-tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ca := newTestCertificate(t, "ca", nil, true)
+	serverCertificate := newTestCertificate(t, "server", ca, false)
+	clientCertificate := newTestCertificate(t, "client", ca, false)
+	certFile, keyFile, caFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), filepath.Join(dir, "ca.crt")
+	modified := time.Now().Add(-time.Minute)
+	writeFile(t, certFile, serverCertificate.certPEM, modified)
+	writeFile(t, keyFile, serverCertificate.keyPEM, modified)
+	writeFile(t, caFile, ca.certPEM, modified)
+
+	cmd := &cobra.Command{}
+	server.Service.ConfigFunc(cmd.PersistentFlags())
+	cmd.SetArgs([]string{
+		"--server-tls-cert=" + certFile,
+		"--server-tls-key=" + keyFile,
+		"--server-tls-client-ca=" + caFile,
+		"--server-tls-reload-interval=10ms",
+	})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, err := server.New(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, ok := server.ClientSubject(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(subject.CommonName))
+	}), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.TLSConfig.MinVersion != tls.VersionTLS12 {
+		t.Fatalf("expected the minimum TLS version to be (%d), got (%d)", tls.VersionTLS12, srv.TLSConfig.MinVersion)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.ServeTLS(listener, "", "")
+	}()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.certificate)
+	keyPair, err := tls.X509KeyPair(clientCertificate.certPEM, clientCertificate.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(certificates ...tls.Certificate) (string, string, error) {
+		client := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certificates},
+			},
+		}
+		response, err := client.Get("https://" + listener.Addr().String())
+		if err != nil {
+			return "", "", err
+		}
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		return string(body), response.TLS.PeerCertificates[0].Subject.CommonName, err
+	}
+
+	body, serverName, err := request(keyPair)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "client" {
+		t.Fatalf("expected the client subject to be (client), got (%s)", body)
+	}
+	if serverName != "server" {
+		t.Fatalf("expected the server certificate to be (server), got (%s)", serverName)
+	}
+	if _, _, err := request(); err == nil {
+		t.Fatal("expected an error without a client certificate, got none")
+	}
+
+	reloaded := newTestCertificate(t, "reloaded", ca, false)
+	writeFile(t, certFile, reloaded.certPEM, time.Now())
+	writeFile(t, keyFile, reloaded.keyPEM, time.Now())
+	deadline := time.Now().Add(5 * time.Second)
+	for serverName != "reloaded" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if _, serverName, err = request(keyPair); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if serverName != "reloaded" {
+		t.Fatalf("expected the server certificate to be (reloaded), got (%s)", serverName)
+	}
+}
+
+func TestNewTLSConfigFails(t *testing.T) {
+	cmd := &cobra.Command{}
+	server.Service.ConfigFunc(cmd.PersistentFlags())
+	cmd.SetArgs([]string{"--server-tls-cert=testdata/missing.crt", "--server-tls-key=testdata/missing.key"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.NewFactory().Configure(cmd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := server.NewTLSConfig(cfg); err == nil {
+		t.Fatal("expected an error, got none")
+	}
+}
@@ -19,7 +19,8 @@ const (
 
 // Service is a dependency that provides an SQS Subscriber for a service
 var Service = dependency.Service{
-	Name: "gizmo-sqs",
+	Name:     "gizmo-sqs",
+	Requires: []string{"config", "logging", "aws-cfg"},
 	ConfigFunc: func(set dependency.FlagSet) {
 		set.String("aws-sqs-queue-name", "", "The name of the SQS Queue you want to read from")
 		set.String("aws-sqs-queue-owner-account-id", "", "The account ID of the owner of the SQS Queue")